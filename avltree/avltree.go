@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/bits"
+	"sort"
 )
 
 type node[T any] struct {
@@ -131,11 +133,11 @@ func (n *node[T]) remove(value T, less LessFunc[T]) (*node[T], bool) {
 
 	bf := n.balanceFactor()
 	// left-left case
-	if bf < -1 && n.left.balanceFactor() < 0 {
+	if bf < -1 && n.left.balanceFactor() <= 0 {
 		return n.rightRotate(), ok
 	}
 	// right-right case
-	if bf > 1 && n.right.balanceFactor() > 0 {
+	if bf > 1 && n.right.balanceFactor() >= 0 {
 		return n.leftRotate(), ok
 	}
 	// left-right case
@@ -167,6 +169,69 @@ func (n *node[T]) remove(value T, less LessFunc[T]) (*node[T], bool) {
 	return n, ok
 }
 
+// inOrder visits the subtree rooted at this node in order, it returns
+// false once visit asked to stop.
+func (n *node[T]) inOrder(visit func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.inOrder(visit) && visit(n.value) && n.right.inOrder(visit)
+}
+
+// reverseOrder visits the subtree rooted at this node in descending order,
+// it returns false once visit asked to stop.
+func (n *node[T]) reverseOrder(visit func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.right.reverseOrder(visit) && visit(n.value) && n.left.reverseOrder(visit)
+}
+
+// morrisStep performs one step of a Morris in-order traversal starting at
+// this node, it returns the node to continue from and the node to visit in
+// this step if any. The traversal temporarily threads the right pointer of
+// the in-order predecessor back to its successor and removes the thread
+// when passing it again, so a traversal run until the returned node is nil
+// leaves the tree as it was.
+func (n *node[T]) morrisStep() (next, visit *node[T]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	pre := n.left
+	for pre.right != nil && pre.right != n {
+		pre = pre.right
+	}
+	if pre.right == nil {
+		pre.right = n // thread back to n
+		return n.left, nil
+	}
+	pre.right = nil // remove the thread, the left subtree is done
+	return n.right, n
+}
+
+// mirror swaps the children of every node in the subtree rooted at this node.
+func (n *node[T]) mirror() {
+	if n == nil {
+		return
+	}
+	n.left, n.right = n.right, n.left
+	n.left.mirror()
+	n.right.mirror()
+}
+
+// clone returns a deep copy of the subtree rooted at this node.
+func (n *node[T]) clone() *node[T] {
+	if n == nil {
+		return nil
+	}
+	return &node[T]{
+		value:  n.value,
+		height: n.height,
+		left:   n.left.clone(),
+		right:  n.right.clone(),
+	}
+}
+
 func (n *node[T]) balanceFactor() int {
 	if n == nil {
 		return 0
@@ -189,9 +254,9 @@ func (n *node[T]) leftRotate() *node[T] {
 	// rotate
 	y.right, x.left = t2, y
 
-	// update height
-	x.height = max(height(x.left), height(x.right)) + 1
+	// update height, y is the child of x now, so it goes first
 	y.height = max(height(y.left), height(y.right)) + 1
+	x.height = max(height(x.left), height(x.right)) + 1
 
 	return x
 }
@@ -211,9 +276,9 @@ func (n *node[T]) rightRotate() *node[T] {
 	// rotate
 	y.left, x.right = t2, y
 
-	// update height
-	x.height = max(height(x.left), height(x.right)) + 1
+	// update height, y is the child of x now, so it goes first
 	y.height = max(height(y.left), height(y.right)) + 1
+	x.height = max(height(x.left), height(x.right)) + 1
 
 	return x
 }
@@ -267,6 +332,35 @@ func (n *node[T]) prettyPrint(sb *bytes.Buffer, padding, pointer string, hasRigh
 	n.right.prettyPrint(sb, padding, rightPointer, false)
 }
 
+// iterator walks a subtree in order with an explicit stack of the
+// ancestors still to visit.
+type iterator[T any] struct {
+	stack []*node[T]
+}
+
+func newIterator[T any](root *node[T]) *iterator[T] {
+	it := &iterator[T]{}
+	it.pushLeft(root)
+	return it
+}
+
+func (it *iterator[T]) pushLeft(n *node[T]) {
+	for ; n != nil; n = n.left {
+		it.stack = append(it.stack, n)
+	}
+}
+
+// next returns the next node in order, or nil once the walk is done.
+func (it *iterator[T]) next() *node[T] {
+	if len(it.stack) == 0 {
+		return nil
+	}
+	n := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	it.pushLeft(n.right)
+	return n
+}
+
 func height[T any](n *node[T]) int {
 	if n == nil {
 		return 0
@@ -285,33 +379,434 @@ func max(a, b int) int {
 // ordering, and should return true if within that ordering, 'a' < 'b'.
 type LessFunc[T any] func(a, b T) bool
 
+// AVLTree is a height-balanced binary search tree. A tree of n values is at
+// most 1.44*log2(n+2) high, e.g. 28 levels for a million values and about
+// 90 for any n an int holds on 64-bit, and Insert and Remove recurse once
+// per level, so their stack usage stays small and bounded whatever the size
+// of the tree.
 type AVLTree[T any] struct {
-	less LessFunc[T]
-	root *node[T]
+	less     LessFunc[T]
+	reversed bool // see Mirror
+	root     *node[T]
+	size     int
+
+	rejected  int    // inserts skipped on an equal value, see RejectedInserts
+	mutations uint64 // bumped by every change of the tree
+	linear    []T    // cached by Linearize, valid while linearAt == mutations
+	linearAt  uint64
 }
 
 func New[T any](less LessFunc[T]) *AVLTree[T] {
 	return &AVLTree[T]{less: less}
 }
 
+// BuildFromSorted returns a tree holding the given values, which must be
+// sorted in strictly ascending order according to less, it panics
+// otherwise. The tree is built in O(n) without rotations by taking the
+// middle value of each range as the root of its subtree.
+func BuildFromSorted[T any](less LessFunc[T], sorted []T) *AVLTree[T] {
+	for i := 1; i < len(sorted); i++ {
+		if !less(sorted[i-1], sorted[i]) {
+			panic(fmt.Sprintf("build input is not strictly sorted at index %d", i))
+		}
+	}
+	a := New[T](less)
+	a.root = buildBalanced(sorted)
+	a.size = len(sorted)
+	return a
+}
+
+// buildBalanced builds a height-balanced subtree of the sorted values.
+func buildBalanced[T any](sorted []T) *node[T] {
+	if len(sorted) == 0 {
+		return nil
+	}
+	mid := len(sorted) / 2
+	n := &node[T]{value: sorted[mid]}
+	n.left = buildBalanced(sorted[:mid])
+	n.right = buildBalanced(sorted[mid+1:])
+	n.height = max(height(n.left), height(n.right)) + 1
+	return n
+}
+
 func (a *AVLTree[T]) Insert(value T) bool {
 	var ok bool
-	a.root, ok = a.root.insert(value, a.less)
+	a.root, ok = a.root.insert(value, a.before)
+	if ok {
+		a.size++
+		a.mutations++
+	} else {
+		a.rejected++
+	}
 	return ok
 }
 
+// RejectedInserts returns how many values Insert or InsertAll skipped for
+// an equal value being in the tree already, since the tree was created or
+// since the last ResetRejectedInserts.
+func (a *AVLTree[T]) RejectedInserts() int {
+	return a.rejected
+}
+
+// ResetRejectedInserts sets the count of RejectedInserts back to zero.
+func (a *AVLTree[T]) ResetRejectedInserts() {
+	a.rejected = 0
+}
+
+// InsertAll inserts the given values, which don't need to be sorted. Values
+// equal to one in the tree, or to an earlier one in the batch, are skipped
+// as by Insert. A small batch is inserted value by value, at O(m log n). A
+// batch large enough that m*log2(n+m) exceeds n+m is sorted and merged with
+// the in-order sequence of the tree instead, which is then rebuilt in
+// O(n + m log m) by BuildFromSorted.
+func (a *AVLTree[T]) InsertAll(values []T) {
+	total := a.size + len(values)
+	if len(values)*bits.Len(uint(total)) <= total {
+		for _, value := range values {
+			a.Insert(value)
+		}
+		return
+	}
+
+	batch := make([]T, len(values))
+	copy(batch, values)
+	sort.SliceStable(batch, func(i, j int) bool { return a.before(batch[i], batch[j]) })
+	merged := make([]T, 0, total)
+	a.InOrder(func(value T) bool {
+		for len(batch) > 0 && !a.before(value, batch[0]) {
+			if a.before(batch[0], value) {
+				merged = appendUnique(merged, batch[0], a.before)
+			}
+			batch = batch[1:]
+		}
+		merged = append(merged, value)
+		return true
+	})
+	for _, value := range batch {
+		merged = appendUnique(merged, value, a.before)
+	}
+	a.rejected += len(values) - (len(merged) - a.size)
+	a.root = buildBalanced(merged)
+	a.size = len(merged)
+	a.mutations++
+}
+
+// appendUnique appends the value to the sorted values unless it equals the
+// last one.
+func appendUnique[T any](sorted []T, value T, less LessFunc[T]) []T {
+	if len(sorted) > 0 && !less(sorted[len(sorted)-1], value) {
+		return sorted
+	}
+	return append(sorted, value)
+}
+
 func (a *AVLTree[T]) Remove(value T) (_ T, _ bool) {
 	var found bool
-	a.root, found = a.root.remove(value, a.less)
+	a.root, found = a.root.remove(value, a.before)
 	if found {
+		a.size--
+		a.mutations++
 		return value, true
 	}
 	return
 }
 
+// Clear removes all values, the tree keeps its ordering and can be refilled.
+func (a *AVLTree[T]) Clear() {
+	a.root, a.size = nil, 0
+	a.mutations++
+}
+
+// Linearize returns the values in ascending order. The sorted values are
+// cached until the next change of the tree, so repeated calls in a
+// read-heavy phase cost a copy of the cache rather than a walk of the tree.
+func (a *AVLTree[T]) Linearize() []T {
+	out := make([]T, a.size)
+	copy(out, a.linearized())
+	return out
+}
+
+// IterateLinearized calls visit for every value in ascending order until
+// visit returns false, like InOrder, but iterates the values cached by
+// Linearize, building the cache first if the tree changed since. visit
+// must not change the tree.
+func (a *AVLTree[T]) IterateLinearized(visit func(T) bool) {
+	for _, value := range a.linearized() {
+		if !visit(value) {
+			return
+		}
+	}
+}
+
+// linearized returns the cached sorted values, rebuilt if stale.
+func (a *AVLTree[T]) linearized() []T {
+	if a.linear == nil || a.linearAt != a.mutations {
+		a.linear = make([]T, 0, a.size)
+		a.InOrder(func(value T) bool {
+			a.linear = append(a.linear, value)
+			return true
+		})
+		a.linearAt = a.mutations
+	}
+	return a.linear
+}
+
+// Contains reports whether a value equal to the given one is in the tree.
+func (a *AVLTree[T]) Contains(value T) bool {
+	n := a.root
+	for n != nil {
+		switch {
+		case a.before(value, n.value):
+			n = n.left
+		case a.before(n.value, value):
+			n = n.right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Depth returns the number of edges between the root and the given value,
+// 0 for the root, or false if the value is not in the tree.
+func (a *AVLTree[T]) Depth(value T) (int, bool) {
+	depth := 0
+	for n := a.root; n != nil; depth++ {
+		switch {
+		case a.before(value, n.value):
+			n = n.left
+		case a.before(n.value, value):
+			n = n.right
+		default:
+			return depth, true
+		}
+	}
+	return 0, false
+}
+
+// Len returns the number of values in the tree.
+func (a *AVLTree[T]) Len() int {
+	return a.size
+}
+
+// Min returns the smallest value, or false if the tree is empty.
+func (a *AVLTree[T]) Min() (_ T, _ bool) {
+	if a.root == nil {
+		return
+	}
+	n := a.root
+	for n.left != nil {
+		n = n.left
+	}
+	return n.value, true
+}
+
+// Max returns the largest value, or false if the tree is empty.
+func (a *AVLTree[T]) Max() (_ T, _ bool) {
+	if a.root == nil {
+		return
+	}
+	n := a.root
+	for n.right != nil {
+		n = n.right
+	}
+	return n.value, true
+}
+
+// PopMin removes and returns the smallest value, or false if the tree is
+// empty. The returned value is the one stored in the tree, not a value
+// merely comparing equal to it.
+func (a *AVLTree[T]) PopMin() (_ T, _ bool) {
+	return a.pop(a.Min)
+}
+
+// PopMax removes and returns the largest value, or false if the tree is
+// empty, see PopMin.
+func (a *AVLTree[T]) PopMax() (_ T, _ bool) {
+	return a.pop(a.Max)
+}
+
+func (a *AVLTree[T]) pop(extreme func() (T, bool)) (_ T, _ bool) {
+	value, ok := extreme()
+	if !ok {
+		return
+	}
+	a.Remove(value)
+	return value, true
+}
+
+// InOrder calls visit for every value in ascending order until visit
+// returns false.
+func (a *AVLTree[T]) InOrder(visit func(T) bool) {
+	a.root.inOrder(visit)
+}
+
+// IterateMutable calls visit for every value in ascending order and removes
+// the current value when visit returns true. The successor of each value is
+// looked up before the value is removed, so the rotations of a removal
+// don't disturb the walk, at the price of O(log n) per step.
+func (a *AVLTree[T]) IterateMutable(visit func(T) (delete bool)) {
+	value, ok := a.Min()
+	for ok {
+		next, hasNext := a.successor(value)
+		if visit(value) {
+			a.Remove(value)
+		}
+		value, ok = next, hasNext
+	}
+}
+
+// successor returns the smallest value greater than the given one, or false
+// if there is none.
+func (a *AVLTree[T]) successor(value T) (out T, found bool) {
+	for n := a.root; n != nil; {
+		if a.before(value, n.value) {
+			out, found = n.value, true
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return
+}
+
+// Kth returns the i-th smallest value, counting from 0, or false if i is out
+// of range. It walks the tree in order and stops at the i-th value, so it
+// costs O(i) rather than the O(log n) of a size-augmented select.
+func (a *AVLTree[T]) Kth(i int) (out T, found bool) {
+	if i < 0 || i >= a.size {
+		return
+	}
+	a.root.inOrder(func(value T) bool {
+		if i == 0 {
+			out, found = value, true
+			return false
+		}
+		i--
+		return true
+	})
+	return
+}
+
+// KthLargest returns the i-th largest value, counting from 0, or false if i
+// is out of range. Like Kth, it costs O(i).
+func (a *AVLTree[T]) KthLargest(i int) (out T, found bool) {
+	if i < 0 || i >= a.size {
+		return
+	}
+	a.root.reverseOrder(func(value T) bool {
+		if i == 0 {
+			out, found = value, true
+			return false
+		}
+		i--
+		return true
+	})
+	return
+}
+
+// InOrderThreaded calls visit for every value in ascending order until visit
+// returns false, like InOrder, but uses a Morris traversal which takes O(1)
+// space instead of a stack proportional to the height. The tree is rethreaded
+// while iterating and fully restored before returning, even if visit panics.
+func (a *AVLTree[T]) InOrderThreaded(visit func(T) bool) {
+	cur := a.root
+	defer func() {
+		// run the rest of the traversal without visiting to remove
+		// the threads left behind by an early stop or a panic.
+		for cur != nil {
+			cur, _ = cur.morrisStep()
+		}
+	}()
+	for cur != nil {
+		var n *node[T]
+		cur, n = cur.morrisStep()
+		if n != nil && !visit(n.value) {
+			return
+		}
+	}
+}
+
+// Mirror reverses the ordering of the tree in place: the children of every
+// node are swapped and the tree orders its values by the reversed less
+// function from now on, so iteration, Min and Max are flipped while Insert
+// and Remove keep working. Mirrored heights are unchanged, hence the tree
+// stays balanced. Mirroring twice restores the original ordering. Rather
+// than wrapping less, which would stack up one wrapper per call, Mirror
+// toggles the reversed flag that before consults on every comparison.
+func (a *AVLTree[T]) Mirror() {
+	a.root.mirror()
+	a.reversed = !a.reversed
+	a.mutations++
+}
+
+// before reports whether x orders before y in the tree, that is by less, or
+// the other way around if the tree is mirrored.
+func (a *AVLTree[T]) before(x, y T) bool {
+	if a.reversed {
+		return a.less(y, x)
+	}
+	return a.less(x, y)
+}
+
+// DescendingCopy returns a copy of the tree ordered the other way around,
+// leaving this tree untouched.
+func (a *AVLTree[T]) DescendingCopy() *AVLTree[T] {
+	out := &AVLTree[T]{less: a.less, reversed: a.reversed, root: a.root.clone(), size: a.size}
+	out.Mirror()
+	return out
+}
+
+// Equal reports whether both trees hold the same sequence of values in order
+// according to eq, regardless of their shapes. It walks both trees in
+// lockstep and stops at the first mismatch.
+func (a *AVLTree[T]) Equal(other *AVLTree[T], eq func(a, b T) bool) bool {
+	if a.size != other.size {
+		return false
+	}
+	x, y := newIterator(a.root), newIterator(other.root)
+	for {
+		nx, ny := x.next(), y.next()
+		if nx == nil || ny == nil {
+			return nx == ny
+		}
+		if !eq(nx.value, ny.value) {
+			return false
+		}
+	}
+}
+
 func (a *AVLTree[T]) Print(w io.Writer) error {
 	if a.root == nil {
 		return nil
 	}
 	return a.root.print(w)
 }
+
+// WriteDOT writes the tree in the Graphviz DOT language, each node labeled
+// with its value and its stored height and linked to its children, so the
+// balance of trees too large for Print can be inspected visually, e.g. with
+// `dot -Tsvg`.
+func (a *AVLTree[T]) WriteDOT(w io.Writer) error {
+	out := &bytes.Buffer{}
+	out.WriteString("digraph AVLTree {\n")
+	id := 0
+	var walk func(n *node[T]) int
+	walk = func(n *node[T]) int {
+		self := id
+		id++
+		fmt.Fprintf(out, "\tn%d [label=%q];\n", self, fmt.Sprintf("%v\nh=%d", n.value, n.height))
+		for _, child := range []*node[T]{n.left, n.right} {
+			if child != nil {
+				fmt.Fprintf(out, "\tn%d -> n%d;\n", self, walk(child))
+			}
+		}
+		return self
+	}
+	if a.root != nil {
+		walk(a.root)
+	}
+	out.WriteString("}\n")
+	_, err := io.Copy(w, out)
+	return err
+}
@@ -0,0 +1,564 @@
+package avltree
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func intLess(a, b int) bool { return a < b }
+
+// newIntTree returns a tree holding the given values.
+func newIntTree(values ...int) *AVLTree[int] {
+	tree := New[int](intLess)
+	for _, value := range values {
+		tree.Insert(value)
+	}
+	return tree
+}
+
+// randomTree returns a tree holding n distinct random values.
+func randomTree(seed int64, n int) *AVLTree[int] {
+	r := rand.New(rand.NewSource(seed))
+	return newIntTree(r.Perm(n)...)
+}
+
+// values returns the values of the tree in ascending order.
+func values(tree *AVLTree[int]) []int {
+	var out []int
+	tree.InOrder(func(value int) bool {
+		out = append(out, value)
+		return true
+	})
+	return out
+}
+
+func TestInOrderThreaded(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		tree := randomTree(int64(n), n)
+		var got []int
+		tree.InOrderThreaded(func(value int) bool {
+			got = append(got, value)
+			return true
+		})
+		if expect := values(tree); !reflect.DeepEqual(got, expect) {
+			t.Fatalf("%d values: expect %v, got %v", n, expect, got)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree := randomTree(1, 100)
+	var got []int
+	tree.InOrderThreaded(func(value int) bool {
+		got = append(got, value)
+		return len(got) < 10
+	})
+	if !reflect.DeepEqual(got, values(tree)[:10]) {
+		t.Fatalf("expect the first 10 values, got %v", got)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("tree not restored after an early stop: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expect the panic to propagate")
+			}
+		}()
+		tree.InOrderThreaded(func(value int) bool {
+			if value == 42 {
+				panic("boom")
+			}
+			return true
+		})
+	}()
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("tree not restored after a panic: %v", err)
+	}
+	if len(values(tree)) != 100 {
+		t.Fatalf("tree lost values after a panic")
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tree := newIntTree(2, 1, 3)
+	for value, expect := range map[int]int{2: 0, 1: 1, 3: 1} {
+		if got, ok := tree.Depth(value); !ok || got != expect {
+			t.Fatalf("depth of %d expect %d, got %d", value, expect, got)
+		}
+	}
+	if _, ok := tree.Depth(4); ok {
+		t.Fatalf("expect an absent value to have no depth")
+	}
+
+	const n = 100000
+	tree = randomTree(1, n)
+	deepest := 0
+	for value := 0; value < n; value++ {
+		depth, ok := tree.Depth(value)
+		if !ok {
+			t.Fatalf("value %d not found", value)
+		}
+		deepest = max(deepest, depth)
+	}
+	// an AVL tree of n nodes is at most ~1.44*log2(n+2) high
+	if bound := 1.4405*math.Log2(n+2) - 0.3277; float64(deepest+1) > bound {
+		t.Fatalf("deepest value at depth %d exceeds the AVL bound %.2f", deepest, bound)
+	}
+}
+
+func reversed(in []int) []int {
+	out := make([]int, len(in))
+	for i, value := range in {
+		out[len(in)-1-i] = value
+	}
+	return out
+}
+
+func TestMirror(t *testing.T) {
+	tree := randomTree(1, 200)
+	ascending := values(tree)
+
+	desc := tree.DescendingCopy()
+	if got := values(desc); !reflect.DeepEqual(got, reversed(ascending)) {
+		t.Fatalf("expect the copy in descending order, got %v", got)
+	}
+	if !reflect.DeepEqual(values(tree), ascending) {
+		t.Fatalf("expect the original untouched by DescendingCopy")
+	}
+
+	tree.Mirror()
+	if got := values(tree); !reflect.DeepEqual(got, reversed(ascending)) {
+		t.Fatalf("expect descending order after Mirror, got %v", got)
+	}
+	if min, _ := tree.Min(); min != 199 {
+		t.Fatalf("expect the mirrored min to be 199, got %d", min)
+	}
+	// the mirrored tree keeps working under the flipped ordering
+	tree.Insert(500)
+	tree.Remove(100)
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := values(tree); got[0] != 500 || tree.Contains(100) {
+		t.Fatalf("unexpected values after mutating the mirrored tree: %v", got[:3])
+	}
+
+	tree.Mirror()
+	if got := values(tree); got[len(got)-1] != 500 {
+		t.Fatalf("expect ascending order after mirroring twice")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if tree.reversed {
+		t.Fatalf("expect mirroring twice to restore the plain ordering")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	eq := func(a, b int) bool { return a == b }
+	ascending := newIntTree(seq(0, 100)...)
+	shuffled := randomTree(7, 100)
+	if !ascending.Equal(shuffled, eq) || !shuffled.Equal(ascending, eq) {
+		t.Fatalf("expect trees of the same set to be equal")
+	}
+	if !New[int](intLess).Equal(New[int](intLess), eq) {
+		t.Fatalf("expect empty trees to be equal")
+	}
+
+	shuffled.Remove(50)
+	shuffled.Insert(1000)
+	if ascending.Equal(shuffled, eq) {
+		t.Fatalf("expect trees of different sets to differ")
+	}
+	shuffled.Remove(1000)
+	if ascending.Equal(shuffled, eq) {
+		t.Fatalf("expect trees of different sizes to differ")
+	}
+}
+
+// seq returns the integers in [lo, hi).
+func seq(lo, hi int) []int {
+	var out []int
+	for i := lo; i < hi; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func TestBuildFromSorted(t *testing.T) {
+	for n := 0; n <= 300; n++ {
+		sorted := seq(0, n)
+		tree := BuildFromSorted(intLess, sorted)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%d values: %v", n, err)
+		}
+		if got := values(tree); !reflect.DeepEqual(got, sorted) && n > 0 {
+			t.Fatalf("%d values: unexpected values %v", n, got)
+		}
+		// the tree keeps working after the build
+		tree.Insert(-1)
+		tree.Insert(n)
+		tree.Remove(n / 2)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%d values: %v after mutation", n, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect a panic on unsorted input")
+		}
+	}()
+	BuildFromSorted(intLess, []int{1, 3, 2})
+}
+
+func TestKth(t *testing.T) {
+	tree := randomTree(3, 50)
+	for i := 0; i < 50; i++ {
+		if got, ok := tree.Kth(i); !ok || got != i {
+			t.Fatalf("Kth(%d) expect %d, got %d-%v", i, i, got, ok)
+		}
+		if got, ok := tree.KthLargest(i); !ok || got != 49-i {
+			t.Fatalf("KthLargest(%d) expect %d, got %d-%v", i, 49-i, got, ok)
+		}
+	}
+	for _, i := range []int{-1, 50, 100} {
+		if _, ok := tree.Kth(i); ok {
+			t.Fatalf("Kth(%d) expect out of range", i)
+		}
+		if _, ok := tree.KthLargest(i); ok {
+			t.Fatalf("KthLargest(%d) expect out of range", i)
+		}
+	}
+}
+
+func TestPanickingCallbacks(t *testing.T) {
+	tree := randomTree(11, 100)
+	other := randomTree(12, 100)
+	m := NewMap[int, int](intLess)
+	for _, key := range seq(0, 100) {
+		m.Insert(key, key)
+	}
+	calls := map[string]func(){
+		"InOrder": func() {
+			tree.InOrder(func(value int) bool {
+				if value == 42 {
+					panic("boom")
+				}
+				return true
+			})
+		},
+		"Equal": func() {
+			tree.Equal(other, func(a, b int) bool { panic("boom") })
+		},
+		"Map.Range": func() {
+			m.Range(10, 90, func(key, _ int) bool {
+				if key == 42 {
+					panic("boom")
+				}
+				return true
+			})
+		},
+	}
+	for name, call := range calls {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expect the panic to propagate", name)
+				}
+			}()
+			call()
+		}()
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%s: tree corrupted after a panic: %v", name, err)
+		}
+		if err := m.tree.Validate(); err != nil {
+			t.Fatalf("%s: map corrupted after a panic: %v", name, err)
+		}
+		if !reflect.DeepEqual(values(tree), seq(0, 100)) || m.Len() != 100 {
+			t.Fatalf("%s: tree changed after a panic", name)
+		}
+	}
+}
+
+func TestInsertAll(t *testing.T) {
+	r := rand.New(rand.NewSource(14))
+	for _, c := range []struct{ size, batch int }{
+		{0, 0}, {0, 50}, {1000, 5}, {1000, 100}, {100, 1000},
+	} {
+		tree := New[int](intLess)
+		ref := map[int]bool{}
+		for i := 0; i < c.size; i++ {
+			value := r.Intn(4 * (c.size + c.batch))
+			tree.Insert(value)
+			ref[value] = true
+		}
+		batch := make([]int, c.batch)
+		for i := range batch {
+			batch[i] = r.Intn(4 * (c.size + c.batch))
+			ref[batch[i]] = true
+		}
+		batch = append(batch, batch[:c.batch/2]...) // duplicates within the batch
+		tree.InsertAll(batch)
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%d+%d: %v", c.size, c.batch, err)
+		}
+		expect := make([]int, 0, len(ref))
+		for value := range ref {
+			expect = append(expect, value)
+		}
+		sort.Ints(expect)
+		if got := values(tree); !reflect.DeepEqual(got, expect) && len(expect) > 0 {
+			t.Fatalf("%d+%d: unexpected values %v", c.size, c.batch, got)
+		}
+	}
+}
+
+func TestLargeTree(t *testing.T) {
+	// ascending inserts rotate on almost every insert.
+	const n = 1 << 20
+	tree := New[int](intLess)
+	for value := 0; value < n; value++ {
+		tree.Insert(value)
+	}
+	bound := func(n int) float64 { return 1.4405*math.Log2(float64(n)+2) - 0.3277 }
+	if h := height(tree.root); float64(h) > bound(n) {
+		t.Fatalf("height %d exceeds the AVL bound %.2f", h, bound(n))
+	}
+	for value := 0; value < n; value += 2 {
+		tree.Remove(value)
+	}
+	if h := height(tree.root); float64(h) > bound(n/2) {
+		t.Fatalf("height %d exceeds the AVL bound %.2f after removals", h, bound(n/2))
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIterateMutable(t *testing.T) {
+	tree := randomTree(16, 300)
+	var visited []int
+	tree.IterateMutable(func(value int) bool {
+		visited = append(visited, value)
+		return value%3 == 0
+	})
+	if !reflect.DeepEqual(visited, seq(0, 300)) {
+		t.Fatalf("expect every value visited once in order, got %v", visited)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var expect []int
+	for _, value := range seq(0, 300) {
+		if value%3 != 0 {
+			expect = append(expect, value)
+		}
+	}
+	if !reflect.DeepEqual(values(tree), expect) {
+		t.Fatalf("expect the multiples of 3 removed, got %v", values(tree))
+	}
+
+	tree.IterateMutable(func(int) bool { return true })
+	if tree.Len() != 0 || tree.root != nil {
+		t.Fatalf("expect an empty tree, got %d values", tree.Len())
+	}
+	tree.IterateMutable(func(int) bool {
+		t.Fatal("expect no value in an empty tree")
+		return false
+	})
+}
+
+func TestClear(t *testing.T) {
+	tree := randomTree(17, 100)
+	tree.Clear()
+	if tree.Len() != 0 || tree.root != nil || tree.Contains(5) {
+		t.Fatalf("expect an empty tree, got %d values", tree.Len())
+	}
+	for _, value := range []int{3, 1, 2} {
+		if !tree.Insert(value) {
+			t.Fatalf("expect %d inserted after a clear", value)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values(tree), []int{1, 2, 3}) {
+		t.Fatalf("expect the ordering kept, got %v", values(tree))
+	}
+}
+
+func TestLinearize(t *testing.T) {
+	tree := New[int](intLess)
+	if got := tree.Linearize(); len(got) != 0 {
+		t.Fatalf("expect no value in an empty tree, got %v", got)
+	}
+	tree = randomTree(20, 50)
+	linearized := func() []int {
+		var out []int
+		tree.IterateLinearized(func(value int) bool {
+			out = append(out, value)
+			return true
+		})
+		return out
+	}
+	if got := tree.Linearize(); !reflect.DeepEqual(got, seq(0, 50)) {
+		t.Fatalf("expect %v, got %v", seq(0, 50), got)
+	}
+	cache := tree.linear
+	if got := linearized(); !reflect.DeepEqual(got, seq(0, 50)) || &tree.linear[0] != &cache[0] {
+		t.Fatalf("expect the cache reused, got %v", got)
+	}
+
+	// the returned slice is a copy.
+	got := tree.Linearize()
+	got[0] = 100
+	if values := linearized(); values[0] != 0 {
+		t.Fatal("expect the cache untouched by editing a linearized copy")
+	}
+
+	mutations := []struct {
+		name   string
+		mutate func()
+		expect []int
+	}{
+		{name: "Insert", mutate: func() { tree.Insert(50) }, expect: seq(0, 51)},
+		{name: "Remove", mutate: func() { tree.Remove(0) }, expect: seq(1, 51)},
+		{name: "InsertAll", mutate: func() { tree.InsertAll(seq(51, 200)) }, expect: seq(1, 200)},
+		{name: "IterateMutable", mutate: func() { tree.IterateMutable(func(v int) bool { return v >= 100 }) }, expect: seq(1, 100)},
+		{name: "Mirror", mutate: func() { tree.Mirror() }, expect: reversed(seq(1, 100))},
+		{name: "Clear", mutate: func() { tree.Clear() }, expect: []int{}},
+	}
+	for _, m := range mutations {
+		linearized() // fill the cache
+		m.mutate()
+		if got := tree.Linearize(); !reflect.DeepEqual(got, m.expect) {
+			t.Fatalf("%s: expect a rebuilt cache %v, got %v", m.name, m.expect, got)
+		}
+		if got := linearized(); !reflect.DeepEqual(got, values(tree)) && len(got) > 0 {
+			t.Fatalf("%s: stale iteration %v", m.name, got)
+		}
+	}
+}
+
+func TestRejectedInserts(t *testing.T) {
+	tree := newIntTree(1, 2, 3)
+	tree.Insert(2)
+	tree.Insert(4)
+	tree.Insert(1)
+	if got := tree.RejectedInserts(); got != 2 {
+		t.Fatalf("expect 2 rejected inserts, got %d", got)
+	}
+	tree.ResetRejectedInserts()
+	if got := tree.RejectedInserts(); got != 0 {
+		t.Fatalf("expect no rejected insert after a reset, got %d", got)
+	}
+
+	// small batches insert value by value, large ones rebuild the tree.
+	tree = randomTree(22, 1000)
+	for _, batch := range [][]int{{4, 1500, 1500}, append(seq(900, 1400), 950, 1200)} {
+		tree.ResetRejectedInserts()
+		size := tree.Len()
+		tree.InsertAll(batch)
+		if expect := len(batch) - (tree.Len() - size); tree.RejectedInserts() != expect {
+			t.Fatalf("batch of %d: expect %d rejected inserts, got %d", len(batch), expect, tree.RejectedInserts())
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New[int](intLess).WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "digraph AVLTree {\n}\n" {
+		t.Fatalf("expect an empty graph, got %q", buf.String())
+	}
+
+	tree := randomTree(47, 100)
+	buf.Reset()
+	if err := tree.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Count(out, "{") != 1 || strings.Count(out, "}") != 1 ||
+		!strings.HasPrefix(out, "digraph AVLTree {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Fatalf("expect a single balanced graph block, got %q", out)
+	}
+	nodes, edges := 0, 0
+	children := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "}":
+		case strings.Contains(line, " -> "):
+			edges++
+			children[strings.Fields(line)[0]]++
+		case strings.Contains(line, "[label="):
+			nodes++
+		default:
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+	if nodes != tree.Len() || edges != tree.Len()-1 {
+		t.Fatalf("expect %d nodes and %d edges, got %d and %d", tree.Len(), tree.Len()-1, nodes, edges)
+	}
+	for id, cnt := range children {
+		if cnt > 2 {
+			t.Fatalf("node %s has %d children", id, cnt)
+		}
+	}
+	root := fmt.Sprintf("n0 [label=%q];", fmt.Sprintf("%v\nh=%d", tree.root.value, tree.root.height))
+	if !strings.Contains(out, root) {
+		t.Fatalf("expect the root labeled with its value and height, got %q", out)
+	}
+}
+
+func TestPopMinMax(t *testing.T) {
+	type item struct {
+		key int
+		tag *string
+	}
+	tree := New[item](func(a, b item) bool { return a.key < b.key })
+	tags := map[int]*string{}
+	for _, key := range rand.New(rand.NewSource(53)).Perm(500) {
+		tag := fmt.Sprint("tag-", key)
+		tags[key] = &tag
+		tree.Insert(item{key: key, tag: &tag})
+	}
+	for expect := 0; expect < 300; expect++ {
+		got, ok := tree.PopMin()
+		if !ok || got.key != expect || got.tag != tags[expect] {
+			t.Fatalf("expect the stored min %d, got %v-%v", expect, got, ok)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%v after popping %d", err, expect)
+		}
+	}
+	for expect := 499; expect >= 300; expect-- {
+		got, ok := tree.PopMax()
+		if !ok || got.key != expect || got.tag != tags[expect] {
+			t.Fatalf("expect the stored max %d, got %v-%v", expect, got, ok)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%v after popping %d", err, expect)
+		}
+	}
+	if _, ok := tree.PopMin(); ok || tree.Len() != 0 {
+		t.Fatal("expect nothing left to pop")
+	}
+	if _, ok := tree.PopMax(); ok {
+		t.Fatal("expect nothing left to pop")
+	}
+}
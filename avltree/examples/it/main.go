@@ -8,11 +8,11 @@ import (
 	"strings"
 
 	"github.com/maxnilz/tree/avltree"
+	"github.com/maxnilz/tree/order"
 )
 
 func main() {
-	less := func(a, b int) bool { return a < b }
-	tree := avltree.New[int](less)
+	tree := avltree.New[int](order.Less[int])
 	value := 0
 	reader := bufio.NewReader(os.Stdin)
 	for {
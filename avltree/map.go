@@ -0,0 +1,170 @@
+package avltree
+
+import "github.com/maxnilz/tree/order"
+
+// entry is a key-value pair stored by the nodes of an AVLMap.
+type entry[K, V any] struct {
+	key   K
+	value V
+}
+
+// AVLMap is an ordered map backed by an AVL tree whose nodes carry
+// key-value entries ordered by key.
+type AVLMap[K, V any] struct {
+	less LessFunc[K]
+	tree *AVLTree[entry[K, V]]
+}
+
+func NewMap[K, V any](less LessFunc[K]) *AVLMap[K, V] {
+	return &AVLMap[K, V]{
+		less: less,
+		tree: New[entry[K, V]](func(a, b entry[K, V]) bool {
+			return less(a.key, b.key)
+		}),
+	}
+}
+
+// find returns the node holding the given key, or nil.
+func (m *AVLMap[K, V]) find(key K) *node[entry[K, V]] {
+	n := m.tree.root
+	for n != nil {
+		switch {
+		case m.less(key, n.value.key):
+			n = n.left
+		case m.less(n.value.key, key):
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// Insert stores the value under the given key, it returns true if the key
+// existed already and its value got replaced.
+func (m *AVLMap[K, V]) Insert(key K, value V) bool {
+	if n := m.find(key); n != nil {
+		n.value.value = value
+		m.tree.mutations++
+		return true
+	}
+	m.tree.Insert(entry[K, V]{key: key, value: value})
+	return false
+}
+
+// Get returns the value stored under the given key, and whether it exists.
+func (m *AVLMap[K, V]) Get(key K) (_ V, _ bool) {
+	if n := m.find(key); n != nil {
+		return n.value.value, true
+	}
+	return
+}
+
+// Remove removes the given key, it returns the value that was stored under
+// it and whether it existed.
+func (m *AVLMap[K, V]) Remove(key K) (_ V, _ bool) {
+	n := m.find(key)
+	if n == nil {
+		return
+	}
+	value := n.value.value
+	m.tree.Remove(n.value)
+	return value, true
+}
+
+// Len returns the number of entries in the map.
+func (m *AVLMap[K, V]) Len() int {
+	return m.tree.Len()
+}
+
+// Floor returns the entry with the largest key less than or equal to the
+// given one, or false if there is none.
+func (m *AVLMap[K, V]) Floor(key K) (K, V, bool) {
+	return m.closest(key, true, true)
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to
+// the given one, or false if there is none.
+func (m *AVLMap[K, V]) Ceiling(key K) (K, V, bool) {
+	return m.closest(key, false, true)
+}
+
+// Predecessor returns the entry with the largest key less than the given
+// one, or false if there is none.
+func (m *AVLMap[K, V]) Predecessor(key K) (K, V, bool) {
+	return m.closest(key, true, false)
+}
+
+// Successor returns the entry with the smallest key greater than the given
+// one, or false if there is none.
+func (m *AVLMap[K, V]) Successor(key K) (K, V, bool) {
+	return m.closest(key, false, false)
+}
+
+// closest descends from the root tracking the best candidate: the entry
+// with the largest key below the given one if below is true, otherwise the
+// one with the smallest key above it, the key itself qualifying if
+// inclusive is true.
+func (m *AVLMap[K, V]) closest(key K, below, inclusive bool) (_ K, _ V, _ bool) {
+	var best *node[entry[K, V]]
+	for n := m.tree.root; n != nil; {
+		k := n.value.key
+		if below {
+			if (inclusive && !m.less(key, k)) || m.less(k, key) {
+				best, n = n, n.right
+			} else {
+				n = n.left
+			}
+			continue
+		}
+		if (inclusive && !m.less(k, key)) || m.less(key, k) {
+			best, n = n, n.left
+		} else {
+			n = n.right
+		}
+	}
+	if best == nil {
+		return
+	}
+	return best.value.key, best.value.value, true
+}
+
+// Range calls visit in ascending key order for the entries whose keys are in
+// [lo, hi) until visit returns false. Subtrees entirely outside the window
+// are not visited.
+func (m *AVLMap[K, V]) Range(lo, hi K, visit func(K, V) bool) {
+	m.rangeFrom(m.tree.root, lo, hi, visit)
+}
+
+// rangeFrom visits the window in the subtree rooted at n, it returns false
+// once visit asked to stop.
+func (m *AVLMap[K, V]) rangeFrom(n *node[entry[K, V]], lo, hi K, visit func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	key := n.value.key
+	aboveLo := !m.less(key, lo) // key >= lo
+	belowHi := m.less(key, hi)  // key < hi
+	if aboveLo && !m.rangeFrom(n.left, lo, hi, visit) {
+		return false
+	}
+	if aboveLo && belowHi && !visit(key, n.value.value) {
+		return false
+	}
+	if belowHi {
+		return m.rangeFrom(n.right, lo, hi, visit)
+	}
+	return true
+}
+
+// SumRange returns the sum of the values whose keys are in [lo, hi). It
+// walks the window with the subtree pruning of Range, so it costs
+// O(log n + k) for k entries in the window.
+func SumRange[K any, V order.Number](m *AVLMap[K, V], lo, hi K) V {
+	var sum V
+	m.Range(lo, hi, func(_ K, value V) bool {
+		sum += value
+		return true
+	})
+	return sum
+}
@@ -0,0 +1,191 @@
+package avltree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap[int, string](intLess)
+	ref := map[int]string{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(200)
+		switch r.Intn(3) {
+		case 0, 1:
+			value := string(rune('a' + i%26))
+			_, exist := ref[key]
+			if replaced := m.Insert(key, value); replaced != exist {
+				t.Fatalf("step %d: insert %d returned %v", i, key, replaced)
+			}
+			ref[key] = value
+		case 2:
+			value, ok := m.Remove(key)
+			expect, exist := ref[key]
+			if ok != exist || value != expect {
+				t.Fatalf("step %d: remove %d expect %q-%v, got %q-%v", i, key, expect, exist, value, ok)
+			}
+			delete(ref, key)
+		}
+	}
+	if m.Len() != len(ref) {
+		t.Fatalf("expect len %d, got %d", len(ref), m.Len())
+	}
+	for key := 0; key < 200; key++ {
+		value, ok := m.Get(key)
+		expect, exist := ref[key]
+		if ok != exist || value != expect {
+			t.Fatalf("get %d expect %q-%v, got %q-%v", key, expect, exist, value, ok)
+		}
+	}
+	if err := m.tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapNeighbors(t *testing.T) {
+	m := NewMap[int, string](intLess)
+	if _, _, ok := m.Floor(1); ok {
+		t.Fatal("expect no floor in an empty map")
+	}
+	for key := 10; key <= 50; key += 10 {
+		m.Insert(key, strconv.Itoa(key*3))
+	}
+	for _, c := range []struct {
+		name   string
+		query  func(int) (int, string, bool)
+		key    int
+		expect int // 0 for none
+	}{
+		{"floor", m.Floor, 30, 30},
+		{"floor", m.Floor, 35, 30},
+		{"floor", m.Floor, 9, 0},
+		{"floor", m.Floor, 99, 50},
+		{"ceiling", m.Ceiling, 30, 30},
+		{"ceiling", m.Ceiling, 35, 40},
+		{"ceiling", m.Ceiling, 51, 0},
+		{"ceiling", m.Ceiling, -1, 10},
+		{"predecessor", m.Predecessor, 30, 20},
+		{"predecessor", m.Predecessor, 35, 30},
+		{"predecessor", m.Predecessor, 10, 0},
+		{"successor", m.Successor, 30, 40},
+		{"successor", m.Successor, 35, 40},
+		{"successor", m.Successor, 50, 0},
+	} {
+		key, value, ok := c.query(c.key)
+		if c.expect == 0 {
+			if ok {
+				t.Fatalf("%s %d: expect none, got %d-%s", c.name, c.key, key, value)
+			}
+			continue
+		}
+		if !ok || key != c.expect || value != strconv.Itoa(c.expect*3) {
+			t.Fatalf("%s %d: expect %d-%d, got %d-%s", c.name, c.key, c.expect, c.expect*3, key, value)
+		}
+	}
+}
+
+func TestMapRange(t *testing.T) {
+	m := NewMap[int, int](intLess)
+	for key := 0; key < 100; key += 2 {
+		m.Insert(key, key*10)
+	}
+	var keys, values []int
+	m.Range(9, 21, func(key, value int) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []int{10, 12, 14, 16, 18, 20}) ||
+		!reflect.DeepEqual(values, []int{100, 120, 140, 160, 180, 200}) {
+		t.Fatalf("unexpected range %v %v", keys, values)
+	}
+
+	keys = keys[:0]
+	m.Range(0, 100, func(key, _ int) bool {
+		keys = append(keys, key)
+		return len(keys) < 3
+	})
+	if !reflect.DeepEqual(keys, []int{0, 2, 4}) {
+		t.Fatalf("expect an early stop after 3 entries, got %v", keys)
+	}
+	m.Range(50, 50, func(int, int) bool {
+		t.Fatalf("expect nothing in an empty window")
+		return false
+	})
+}
+
+func TestMapRangePruning(t *testing.T) {
+	compares := 0
+	m := NewMap[int, int](func(a, b int) bool {
+		compares++
+		return a < b
+	})
+	r := rand.New(rand.NewSource(31))
+	var ref []int
+	for _, key := range r.Perm(1 << 14) {
+		if key%3 != 0 {
+			m.Insert(key, -key)
+			ref = append(ref, key)
+		}
+	}
+	sort.Ints(ref)
+	const height = 20 // bound of an AVL tree of ~11k entries, 1.44 log2(n)
+
+	for i := 0; i < 200; i++ {
+		lo := r.Intn(1<<14+10) - 5
+		hi := lo + r.Intn(40)
+		var expect, got []int
+		for _, key := range ref {
+			if key >= lo && key < hi {
+				expect = append(expect, key)
+			}
+		}
+		compares = 0
+		m.Range(lo, hi, func(key, value int) bool {
+			if value != -key {
+				t.Fatalf("key %d: expect value %d, got %d", key, -key, value)
+			}
+			got = append(got, key)
+			return true
+		})
+		if !reflect.DeepEqual(got, expect) {
+			t.Fatalf("[%d, %d): expect %v, got %v", lo, hi, expect, got)
+		}
+		// Each visited node costs two comparisons, a pruned walk visits
+		// the two boundary paths and the entries in between only.
+		if bound := 2 * (2*height + len(expect)); compares > bound {
+			t.Fatalf("[%d, %d): expect at most %d comparisons, got %d", lo, hi, bound, compares)
+		}
+	}
+}
+
+func TestSumRange(t *testing.T) {
+	m := NewMap[int, float64](intLess)
+	if sum := SumRange(m, 0, 10); sum != 0 {
+		t.Fatalf("expect 0 on an empty map, got %v", sum)
+	}
+	r := rand.New(rand.NewSource(41))
+	values := map[int]float64{}
+	for i := 0; i < 2000; i++ {
+		key, value := r.Intn(1000), float64(r.Intn(100))/4
+		m.Insert(key, value)
+		values[key] = value
+	}
+	for i := 0; i < 100; i++ {
+		lo := r.Intn(1100) - 50
+		hi := lo + r.Intn(300)
+		var expect float64
+		for key, value := range values {
+			if key >= lo && key < hi {
+				expect += value
+			}
+		}
+		if sum := SumRange(m, lo, hi); sum != expect {
+			t.Fatalf("[%d, %d): expect %v, got %v", lo, hi, expect, sum)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package avltree
+
+import "fmt"
+
+// Validate checks the invariants of the tree: the ordering, the stored
+// heights and the AVL balance of every node, plus the size counter. It
+// returns an error describing the first violation, or nil for a well-formed
+// tree. It walks the whole tree, so it costs O(n).
+func (a *AVLTree[T]) Validate() error {
+	cnt := 0
+	var walk func(n *node[T], lo, hi *T) (int, error)
+	walk = func(n *node[T], lo, hi *T) (int, error) {
+		if n == nil {
+			return 0, nil
+		}
+		cnt++
+		if (lo != nil && !a.before(*lo, n.value)) || (hi != nil && !a.before(n.value, *hi)) {
+			return 0, fmt.Errorf("value %v out of order", n.value)
+		}
+		lh, err := walk(n.left, lo, &n.value)
+		if err != nil {
+			return 0, err
+		}
+		rh, err := walk(n.right, &n.value, hi)
+		if err != nil {
+			return 0, err
+		}
+		if n.height != max(lh, rh)+1 {
+			return 0, fmt.Errorf("value %v has height %d, expect %d", n.value, n.height, max(lh, rh)+1)
+		}
+		if lh-rh > 1 || rh-lh > 1 {
+			return 0, fmt.Errorf("value %v is unbalanced: %d vs %d", n.value, lh, rh)
+		}
+		return n.height, nil
+	}
+	if _, err := walk(a.root, nil, nil); err != nil {
+		return err
+	}
+	if cnt != a.size {
+		return fmt.Errorf("tree holds %d values, size is %d", cnt, a.size)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package bplustree
+
+import (
+	"sync"
+
+	"github.com/maxnilz/tree/order"
+)
+
+// SumRange returns the sum of the values whose keys are in [lo, hi).
+func SumRange[kT any](t *BPlusTree[kT, int64], lo, hi kT) int64 {
+	return SumRangeOf(t, lo, hi)
+}
+
+// SumRangeOf returns the sum of the values whose keys are in [lo, hi) for
+// any numeric value type. It scans the entries of the range along the leaf
+// chain, so it costs O(log n + k) for k entries in the range. Keeping the
+// sum of every subtree in the internal nodes would bring it to O(log n).
+func SumRangeOf[kT any, vT order.Number](t *BPlusTree[kT, vT], lo, hi kT) vT {
+	var sum vT
+	for c := t.GetRange(lo, hi); c.Valid(); c.Next() {
+		sum += c.Value()
+	}
+	return sum
+}
+
+// ParallelScan calls fn for every entry of the tree on workers goroutines:
+// the leaf chain is cut into as many contiguous segments holding about the
+// same number of entries, each scanned by its own goroutine in ascending
+// key order. The order of the calls across segments is unspecified. fn must
+// be safe for concurrent calls, and the tree must not be mutated until
+// ParallelScan returns.
+func (t *BPlusTree[kT, vT]) ParallelScan(workers int, fn func(key kT, value vT)) {
+	if t.root == nil {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	var leaves []*node[kT, vT]
+	for n := t.root.leftmostLeaf(); n != nil; n = n.next {
+		leaves = append(leaves, n)
+	}
+
+	var wg sync.WaitGroup
+	scan := func(segment []*node[kT, vT]) {
+		defer wg.Done()
+		for _, n := range segment {
+			for i, key := range n.keys {
+				fn(key, n.values[i])
+			}
+		}
+	}
+	// cut after the leaf where the running count of entries reaches the
+	// next share of the total.
+	start, seen, segments := 0, 0, 0
+	for i, n := range leaves {
+		seen += len(n.keys)
+		if segments < workers-1 && seen*workers >= (segments+1)*t.length {
+			wg.Add(1)
+			go scan(leaves[start : i+1])
+			start, segments = i+1, segments+1
+		}
+	}
+	if start < len(leaves) {
+		wg.Add(1)
+		go scan(leaves[start:])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+package bplustree
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSumRange(t *testing.T) {
+	tree := New[int, int64](4, intLess)
+	for key := 0; key < 100; key++ {
+		tree.Insert(key, int64(key))
+	}
+	cases := []struct {
+		lo, hi int
+		expect int64
+	}{
+		{lo: 0, hi: 100, expect: 4950},
+		{lo: 10, hi: 20, expect: 145},
+		{lo: 10, hi: 10, expect: 0},
+		{lo: 99, hi: 1000, expect: 99},
+		{lo: 200, hi: 300, expect: 0},
+	}
+	for _, c := range cases {
+		if got := SumRange(tree, c.lo, c.hi); got != c.expect {
+			t.Fatalf("SumRange(%d, %d) expect %d, got %d", c.lo, c.hi, c.expect, got)
+		}
+	}
+
+	floats := New[string, float64](4, func(a, b string) bool { return a < b })
+	floats.Insert("a", 0.5)
+	floats.Insert("b", 1.25)
+	floats.Insert("c", 2)
+	if got := SumRangeOf(floats, "a", "c"); got != 1.75 {
+		t.Fatalf("expect 1.75, got %v", got)
+	}
+}
+
+func TestParallelScan(t *testing.T) {
+	New[int, int](4, intLess).ParallelScan(4, func(int, int) {
+		t.Fatal("expect no entry in an empty tree")
+	})
+	tree := newIntTree(4, seq(0, 1000, 1)...)
+	for _, workers := range []int{0, 1, 3, 8, 2000} {
+		var mu sync.Mutex
+		seen := make(map[int]int)
+		var sum int64
+		tree.ParallelScan(workers, func(key, value int) {
+			atomic.AddInt64(&sum, int64(value))
+			mu.Lock()
+			seen[key]++
+			mu.Unlock()
+		})
+		if sum != 4995000 {
+			t.Fatalf("%d workers: expect the sum 4995000, got %d", workers, sum)
+		}
+		for key := 0; key < 1000; key++ {
+			if seen[key] != 1 {
+				t.Fatalf("%d workers: key %d visited %d times", workers, key, seen[key])
+			}
+		}
+	}
+}
+
+func BenchmarkParallelScan(b *testing.B) {
+	const n = 10000000
+	pairs := make([]Pair[int, int], n)
+	for i := range pairs {
+		pairs[i] = Pair[int, int]{Key: i, Value: i}
+	}
+	tree := New[int, int](64, intLess)
+	tree.BulkLoad(pairs)
+	pairs = nil
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			// one partial sum per worker share, a cache line apart, so the
+			// workers don't contend on a single counter.
+			sums := make([]int64, 8*workers)
+			for i := 0; i < b.N; i++ {
+				tree.ParallelScan(workers, func(key, value int) {
+					atomic.AddInt64(&sums[8*(key*workers/n)], int64(value))
+				})
+			}
+		})
+	}
+}
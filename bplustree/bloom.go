@@ -0,0 +1,121 @@
+package bplustree
+
+import "math"
+
+// Option configures a tree at construction time.
+type Option[kT, vT any] func(*BPlusTree[kT, vT])
+
+// WithBloomFilter keeps a Bloom filter on every leaf, which Get consults to
+// short-circuit lookups of absent keys before searching the leaf. hash must
+// spread the keys well over 64 bits, keys equal under the tree ordering
+// must hash equally. falsePositiveRate is the targeted rate of absent keys
+// that still get searched, for a full leaf. Every leaf gets its filter when
+// it is created, inserts add their key to it, and it is rebuilt after splits
+// and merges, or once more keys got removed from the leaf than it still
+// holds. Get only reads the filters, so concurrent Gets stay safe.
+func WithBloomFilter[kT, vT any](hash func(kT) uint64, falsePositiveRate float64) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		t.bloom = newBloomConfig(hash, t.order, falsePositiveRate)
+	}
+}
+
+// bloomConfig sizes the filters of the leaves of a tree.
+type bloomConfig[kT any] struct {
+	hash   func(kT) uint64
+	bits   int // number of bits of a filter
+	hashes int // number of hash functions
+}
+
+func newBloomConfig[kT any](hash func(kT) uint64, capacity int, falsePositiveRate float64) *bloomConfig[kT] {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	// the usual optimal sizing for n keys: m = -n*ln(p)/ln(2)^2, k = m/n*ln(2)
+	n := float64(capacity + 1) // a leaf may briefly hold one more key on a split
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/n*math.Ln2))
+	return &bloomConfig[kT]{hash: hash, bits: int(m), hashes: int(k)}
+}
+
+// build returns a filter holding the given keys.
+func (c *bloomConfig[kT]) build(keys []kT) *bloomFilter[kT] {
+	f := &bloomFilter[kT]{
+		cfg:  c,
+		bits: make([]uint64, (c.bits+63)/64),
+	}
+	for _, key := range keys {
+		f.add(key)
+	}
+	return f
+}
+
+// bloomFilter answers whether a key may be in a leaf. Removed keys are not
+// cleared from it, so it is a superset of the leaf's keys which is rebuilt
+// once too many keys have been removed.
+type bloomFilter[kT any] struct {
+	cfg     *bloomConfig[kT]
+	bits    []uint64
+	removed int
+}
+
+func (f *bloomFilter[kT]) add(key kT) {
+	h1, h2 := f.split(key)
+	for i := 0; i < f.cfg.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(f.cfg.bits)
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (f *bloomFilter[kT]) mayContain(key kT) bool {
+	h1, h2 := f.split(key)
+	for i := 0; i < f.cfg.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(f.cfg.bits)
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// split derives the two hashes of the double hashing scheme from the key.
+func (f *bloomFilter[kT]) split(key kT) (uint64, uint64) {
+	h := f.cfg.hash(key)
+	// splitmix64 finalizer, decorrelates the second hash from the first.
+	z := h + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return h, z | 1
+}
+
+// mayContain reports whether the leaf may hold the key according to its
+// filter, true if the leaf has none.
+func (n *node[kT, vT]) mayContain(key kT) bool {
+	return n.bloom == nil || n.bloom.mayContain(key)
+}
+
+// bloomAdd adds the key to the filter of this leaf, if it has one.
+func (n *node[kT, vT]) bloomAdd(key kT) {
+	if n.bloom != nil {
+		n.bloom.add(key)
+	}
+}
+
+// bloomRemove counts a key removed from this leaf, and rebuilds its filter
+// once more keys got removed than the leaf still holds.
+func (n *node[kT, vT]) bloomRemove() {
+	if n.bloom == nil {
+		return
+	}
+	n.bloom.removed++
+	if n.bloom.removed > len(n.keys) {
+		n.bloomRebuild()
+	}
+}
+
+// bloomRebuild rebuilds the filter of this leaf from its keys, if it has one.
+func (n *node[kT, vT]) bloomRebuild() {
+	if n.bloom != nil {
+		n.bloom = n.bloom.cfg.build(n.keys)
+	}
+}
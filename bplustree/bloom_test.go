@@ -0,0 +1,108 @@
+package bplustree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func intHash(key int) uint64 {
+	return uint64(key) * 0x9e3779b97f4a7c15
+}
+
+func TestBloomFilter(t *testing.T) {
+	tree := New[int, int](16, intLess, WithBloomFilter[int, int](intHash, 0.01))
+	ref := map[int]int{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		key := r.Intn(2000)
+		switch r.Intn(3) {
+		case 0, 1:
+			tree.Insert(key, i)
+			ref[key] = i
+		case 2:
+			tree.Remove(key)
+			delete(ref, key)
+		}
+		probe := r.Intn(2000)
+		value, ok := tree.Get(probe)
+		if expect, exist := ref[probe]; ok != exist || value != expect {
+			t.Fatalf("step %d: get %d expect %d-%v, got %d-%v", i, probe, expect, exist, value, ok)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBloomFilterConcurrentGet runs Gets from several goroutines on a tree
+// whose leaves saw heavy removals, run it with -race to check that Get only
+// reads the filters.
+func TestBloomFilterConcurrentGet(t *testing.T) {
+	tree := New[int, int](16, intLess, WithBloomFilter[int, int](intHash, 0.01))
+	for key := 0; key < 2000; key++ {
+		tree.Insert(key, key*10)
+	}
+	for key := 0; key < 2000; key++ {
+		if key%4 != 0 {
+			tree.Remove(key)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := 0; key < 2000; key++ {
+				value, ok := tree.Get(key)
+				if ok != (key%4 == 0) || ok && value != key*10 {
+					t.Errorf("get %d: got %d-%v", key, value, ok)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	cfg := newBloomConfig(intHash, 64, 0.01)
+	f := cfg.build(seq(0, 64*1000, 1000))
+	for key := 0; key < 64*1000; key += 1000 {
+		if !f.mayContain(key) {
+			t.Fatalf("false negative for %d", key)
+		}
+	}
+	positives := 0
+	for key := 1; key < 100000; key += 2 {
+		if f.mayContain(key) {
+			positives++
+		}
+	}
+	if rate := float64(positives) / 50000; rate > 0.03 {
+		t.Fatalf("false positive rate %.3f is too far above the target", rate)
+	}
+}
+
+func benchmarkGetMiss(b *testing.B, opts ...Option[int, int]) {
+	tree := New[int, int](64, intLess, opts...)
+	for key := 0; key < 1000000; key += 2 {
+		tree.Insert(key, key)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get((i*7919)%1000000 | 1) // odd keys are all absent
+	}
+}
+
+func BenchmarkGetMiss(b *testing.B) {
+	b.Run("plain", func(b *testing.B) {
+		benchmarkGetMiss(b)
+	})
+	b.Run("bloom", func(b *testing.B) {
+		benchmarkGetMiss(b, WithBloomFilter[int, int](intHash, 0.01))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"sort"
+	"sync/atomic"
 )
 
 // items stores items in a node.
@@ -64,7 +65,8 @@ func (s *items[T]) truncate(index int) {
 
 // find returns the index where the given item should be inserted into this
 // list.  'found' is true if the item already exists in the list at the given
-// index.
+// index. The binary search beats a linear scan even on the small nodes of
+// order 4, see findLinear in BenchmarkFind.
 func (s items[T]) find(item T, less func(T, T) bool) (index int, found bool) {
 	i := sort.Search(len(s), func(i int) bool {
 		return less(item, s[i])
@@ -81,7 +83,7 @@ func (s items[T]) find(item T, less func(T, T) bool) (index int, found bool) {
 //
 // In a leaf, children would be nil and the index of
 // each key equals the index of its corresponding values,
-// with a maximum of order-1 key-value pairs.
+// with a maximum of order key-value pairs.
 //
 // In an internal node, the first child refers to lower
 // nodes with keys less than the smallest key in the keys
@@ -99,12 +101,26 @@ type node[kT, vT any] struct {
 	order int
 	next  *node[kT, vT]
 	prev  *node[kT, vT]
+	gen   uint64 // see SnapshotCursor
 
 	// leaf only
-	isLeaf bool
-	values items[vT]
+	isLeaf      bool
+	values      items[vT]
+	bloom       *bloomFilter[kT]  // nil unless the tree has WithBloomFilter
+	slack       int               // see WithMergeHysteresis
+	store       LeafStore[kT, vT] // nil for a leaf outside of a tree
+	appendSplit bool              // see WithAppendOptimizedSplit
 }
 
+// The capacity of a node is bounded by the order: a leaf holds between
+// ceil(order/2) and order entries, and an internal node has between
+// ceil(order/2) and order children, hence between ceil(order/2)-1 and
+// order-1 keys. The root is exempt from the minimum. Leaves count their
+// entries and internal nodes their children against the order, which is
+// why their key bounds differ by one. For even and odd orders alike,
+// splitting an overflowed node at minKeys leaves both halves at or above
+// the minimum, and merging a node one below the minimum with a sibling at
+// the minimum fits in maxKeys, separator included for internal nodes.
 func (n *node[kT, vT]) maxKeys() int {
 	if !n.isLeaf {
 		return n.order - 1
@@ -120,6 +136,15 @@ func (n *node[kT, vT]) minKeys() int {
 	return degree
 }
 
+// mergeKeys returns the fewest keys this node may hold before a removal
+// rebalances it, which is minKeys lowered by the merge slack for a leaf.
+func (n *node[kT, vT]) mergeKeys() int {
+	if !n.isLeaf {
+		return n.minKeys()
+	}
+	return n.minKeys() - n.slack
+}
+
 // split splits the given node at the given index. The current node shrinks,
 // if the current node is an internal node, it returns the key that existed
 // at that index and a new node containing all keys/children after the given
@@ -127,24 +152,30 @@ func (n *node[kT, vT]) minKeys() int {
 // index and a new node containing all keys/values at & after the given index.
 func (n *node[kT, vT]) split(i int) (kT, *node[kT, vT]) {
 	key := n.keys[i]
-	newNode := &node[kT, vT]{}
+	newNode := &node[kT, vT]{order: n.order, isLeaf: n.isLeaf, gen: n.gen}
 	ik := i + 1
 	if n.isLeaf {
 		ik = i
+		newNode.slack, newNode.store, newNode.appendSplit = n.slack, n.store, n.appendSplit
+		newNode.bloom = n.bloom
+		newNode.alloc()
 	}
 	newNode.keys = append(newNode.keys, n.keys[ik:]...)
 	n.keys.truncate(i)
 	if len(n.children) > 0 {
 		newNode.children = append(newNode.children, n.children[i+1:]...)
 		n.children.truncate(i + 1)
+		for _, child := range newNode.children {
+			child.parent = newNode
+		}
 	}
-	newNode.order = n.order
 	newNode.parent = n.parent
-	newNode.isLeaf = n.isLeaf
 	if len(n.values) > 0 {
 		newNode.values = append(newNode.values, n.values[i:]...)
 		n.values.truncate(i)
 	}
+	n.bloomRebuild()
+	newNode.bloomRebuild()
 	if n.next != nil {
 		n.next.prev = newNode
 	}
@@ -154,147 +185,252 @@ func (n *node[kT, vT]) split(i int) (kT, *node[kT, vT]) {
 	return key, newNode
 }
 
+// childIndex returns the index of the child whose subtree the given key
+// belongs to.
+func (n *node[kT, vT]) childIndex(key kT, less LessFunc[kT]) int {
+	i, found := n.keys.find(key, less)
+	if found {
+		i++ // keys equal to the separator live in the right subtree
+	}
+	return i
+}
+
 // insert inserts a key-value pair into the subtree rooted at this node,
-// making sure no nodes in the subtree exceed order-1 keys. it will replace the value
-// if the given key existed already and return false to indicate that no new key is
-// inserted, otherwise, return the true and newly created node if a split happens.
-func (n *node[kT, vT]) insert(key kT, value vT, less LessFunc[kT]) (*node[kT, vT], bool) {
+// making sure no nodes in the subtree exceed their capacity, order entries
+// for a leaf and order-1 keys for an internal node. it will replace the value
+// if the given key existed already, by the one resolve returns if not nil, and
+// return true to indicate that no new key is inserted, otherwise, return false and
+// the newly created root if a split reaches the root, along with the number of
+// nodes the insertion split.
+func (n *node[kT, vT]) insert(key kT, value vT, less LessFunc[kT], resolve ConflictFunc[kT, vT]) (*node[kT, vT], bool, int) {
 	if n.isLeaf {
-		return n.insertIntoLeaf(key, value, less)
+		return n.insertIntoLeaf(key, value, less, resolve)
 	}
-	i, _ := n.keys.find(key, less)
-	return n.children[i].insert(key, value, less)
+	i := n.childIndex(key, less)
+	return n.children[i].insert(key, value, less, resolve)
 }
 
-func (n *node[kT, vT]) insertIntoLeaf(key kT, value vT, less LessFunc[kT]) (*node[kT, vT], bool) {
+func (n *node[kT, vT]) insertIntoLeaf(key kT, value vT, less LessFunc[kT], resolve ConflictFunc[kT, vT]) (*node[kT, vT], bool, int) {
 	index, found := n.keys.find(key, less)
 	if found {
+		if resolve != nil {
+			value = resolve(key, n.values[index], value)
+		}
 		n.values[index] = value
-		return nil, false
+		return nil, true, 0
 	}
 	n.keys.insertAt(index, key)
 	n.values.insertAt(index, value)
-	return n.mayGrowUp(less)
+	n.bloomAdd(key)
+	at := n.minKeys()
+	if n.appendSplit && n.next == nil && index == len(n.keys)-1 {
+		at = n.maxKeys() // keep the last leaf full, move the new key alone
+	}
+	root, splits := n.mayGrowUp(at, less)
+	return root, false, splits
 }
 
-func (n *node[kT, vT]) mayGrowUp(less LessFunc[kT]) (*node[kT, vT], bool) {
+// mayGrowUp splits this node at the given index if it overflows, and its
+// ancestors at their minKeys as long as they overflow in turn, it returns the
+// new root if the splits reach the root and the number of nodes split.
+func (n *node[kT, vT]) mayGrowUp(at int, less LessFunc[kT]) (*node[kT, vT], int) {
 	if len(n.keys) <= n.maxKeys() {
-		return nil, false
+		return nil, 0
 	}
-	promotedKey, newNode := n.split(n.minKeys())
+	promotedKey, newNode := n.split(at)
 	parent := n.parent
 	if parent == nil {
 		root := &node[kT, vT]{
 			order: n.order,
+			gen:   n.gen,
 		}
 		root.keys = append(root.keys, promotedKey)
 		root.children = append(root.children, n, newNode)
 		n.parent = root
 		newNode.parent = root
-		return root, true
+		return root, 1
 	}
 
 	index, _ := parent.keys.find(promotedKey, less)
 	parent.keys.insertAt(index, promotedKey)
 	parent.children.insertAt(index+1, newNode)
-	return parent.mayGrowUp(less)
-}
-
-// remove removes an item from the subtree rooted at this node.
-// if no key found in the leaf node of the subtree, return false, otherwise, remove
-// it from leaf node, then the stop node(if merge happens), removed value and true.
-func (n *node[kT, vT]) remove(key kT, less LessFunc[kT]) (_ *node[kT, vT], _ vT, _ bool) {
-	if n.isLeaf {
-		return n.removeFromLeaf(key, less)
-	}
-	i, _ := n.keys.find(key, less)
-	return n.children[i].remove(key, less)
+	root, splits := parent.mayGrowUp(parent.minKeys(), less)
+	return root, splits + 1
 }
 
-func (n *node[kT, vT]) removeFromLeaf(key kT, less LessFunc[kT]) (stopAt *node[kT, vT], out vT, found bool) {
+// removeFromLeaf removes an item from this leaf, the one the key belongs
+// to, if the key is found there, and rebalances back up along the parent
+// pointers iteratively. It returns false if the key is absent, otherwise the
+// new root (if a merge shrinks the root), the removed value and true.
+func (n *node[kT, vT]) removeFromLeaf(key kT, less LessFunc[kT]) (root *node[kT, vT], out vT, found bool) {
 	var index int
 	index, found = n.keys.find(key, less)
 	if !found {
 		return
 	}
-	n.keys.removeAt(index)
-	out = n.values.removeAt(index)
-	if n.parent == nil || len(n.keys) >= n.minKeys() {
-		return // still valid after the removal, return directly
+	root, _, out = n.removeEntry(index)
+	return
+}
+
+// removeEntry removes the entry at the given index of this leaf and
+// rebalances the tree, it returns the new root if the root changed, and the
+// removed key and value.
+func (n *node[kT, vT]) removeEntry(index int) (root *node[kT, vT], key kT, value vT) {
+	key = n.keys.removeAt(index)
+	value = n.values.removeAt(index)
+	n.bloomRemove()
+	root = n.rebalance()
+	return
+}
+
+// rebalance restores the minimum fill of this node after a removal by
+// stealing from or merging with a sibling, walking up to the root as long
+// as merges leave the parent underflowed. It returns the new root if the
+// old one ran out of keys, or nil.
+func (n *node[kT, vT]) rebalance() *node[kT, vT] {
+	for n.parent != nil && len(n.keys) < n.mergeKeys() {
+		if n.mayStealFromNeighbor() {
+			return nil
+		}
+		parent := n.mergeWithNeighbor()
+		if parent.parent == nil && len(parent.keys) == 0 {
+			// the root has a single child left, shrink the tree.
+			root := parent.children[0]
+			root.parent = nil
+			parent.detach()
+			return root
+		}
+		n = parent
 	}
-	if n.mayStealFromNeighborLeaf(key, less) {
-		return
+	return nil // still valid after the removal
+}
+
+// indexInParent returns the index of this node in the children of its parent.
+func (n *node[kT, vT]) indexInParent() int {
+	for i, child := range n.parent.children {
+		if child == n {
+			return i
+		}
 	}
-	stopAt, _ = n.mayMergeWithNeighbor(key, less)
-	return
+	panic("node is not a child of its parent")
 }
 
-func (n *node[kT, vT]) mayStealFromNeighborLeaf(key kT, less LessFunc[kT]) bool {
-	if !n.isLeaf {
-		panic("unexpected steal operation")
-	}
-	prev, next := n.prev, n.next
-	if prev != nil && len(prev.keys) > prev.minKeys() {
-		stolenKey := prev.keys.pop()
-		stolenValue := prev.values.pop()
-		n.keys.insertAt(0, stolenKey)
-		n.values.insertAt(0, stolenValue)
-		parent := n.parent
-		index, _ := parent.keys.find(key, less)
-		parent.keys.removeAt(index)
-		parent.keys.insertAt(index, stolenKey)
-		return true
+// mayStealFromNeighbor moves one entry from a sibling having more than the
+// mergeKeys number of keys into this node, rotating through the separator key
+// in the parent. Only siblings sharing the same parent are considered.
+func (n *node[kT, vT]) mayStealFromNeighbor() bool {
+	parent := n.parent
+	i := n.indexInParent()
+	if i > 0 {
+		prev := parent.children[i-1]
+		if len(prev.keys) > prev.mergeKeys() {
+			if n.isLeaf {
+				n.keys.insertAt(0, prev.keys.pop())
+				n.values.insertAt(0, prev.values.pop())
+				parent.keys[i-1] = n.keys[0]
+				n.bloomAdd(n.keys[0])
+				prev.bloomRemove()
+				return true
+			}
+			n.keys.insertAt(0, parent.keys[i-1])
+			parent.keys[i-1] = prev.keys.pop()
+			child := prev.children.pop()
+			child.parent = n
+			n.children.insertAt(0, child)
+			return true
+		}
 	}
-	if next != nil && len(next.keys) > next.minKeys() {
-		stolenKey := next.keys.removeAt(0)
-		stolenValue := next.values.removeAt(0)
-		n.keys = append(n.keys, stolenKey)
-		n.values = append(n.values, stolenValue)
-
-		shiftUpKey := next.keys[0]
-		parent := n.parent
-		index, _ := parent.keys.find(key, less)
-		parent.keys.removeAt(index)
-		parent.keys.insertAt(index, shiftUpKey)
-		return true
+	if i < len(parent.children)-1 {
+		next := parent.children[i+1]
+		if len(next.keys) > next.mergeKeys() {
+			if n.isLeaf {
+				n.keys = append(n.keys, next.keys.removeAt(0))
+				n.values = append(n.values, next.values.removeAt(0))
+				parent.keys[i] = next.keys[0]
+				n.bloomAdd(n.keys[len(n.keys)-1])
+				next.bloomRemove()
+				return true
+			}
+			n.keys = append(n.keys, parent.keys[i])
+			parent.keys[i] = next.keys.removeAt(0)
+			child := next.children.removeAt(0)
+			child.parent = n
+			n.children = append(n.children, child)
+			return true
+		}
 	}
 	return false
 }
 
-func (n *node[kT, vT]) mayMergeWithNeighbor(key kT, less LessFunc[kT]) (*node[kT, vT], bool) {
-	first, second := n.prev, n
-	if n.prev == nil {
-		first, second = n, n.next
-	}
-	if len(first.keys)+len(second.keys) > first.maxKeys() {
-		return n, false
+// mergeWithNeighbor merges this node with its left sibling, or with its
+// right sibling if it is the first child, and removes the separator key
+// from the parent. For an internal node the separator is pulled down into
+// the merged node. It returns the parent.
+func (n *node[kT, vT]) mergeWithNeighbor() *node[kT, vT] {
+	parent := n.parent
+	i := n.indexInParent()
+	if i == 0 {
+		i++ // merge the right sibling into this node instead
 	}
+	first, second := parent.children[i-1], parent.children[i]
 
+	if !first.isLeaf {
+		first.keys = append(first.keys, parent.keys[i-1])
+	}
 	first.keys = append(first.keys, second.keys...)
-	first.children = append(first.children, second.children...)
 	first.values = append(first.values, second.values...)
+	for _, child := range second.children {
+		child.parent = first
+	}
+	first.children = append(first.children, second.children...)
 	first.next = second.next
 	if second.next != nil {
 		second.next.prev = first
 	}
+	first.bloomRebuild()
+	second.detach()
 
-	parent := n.parent
-	i, _ := parent.keys.find(key, less)
-	if i == len(parent.keys) {
-		i = i - 1
+	parent.keys.removeAt(i - 1)
+	parent.children.removeAt(i)
+	return parent
+}
+
+// detach clears the content and the links of a node dropped from the tree,
+// so a reference to it that outlives the removal, e.g. from a stale cursor,
+// doesn't keep other nodes, keys or values reachable.
+func (n *node[kT, vT]) detach() {
+	if n.isLeaf {
+		n.free()
 	}
-	parent.keys.removeAt(i)
-	parent.children.removeAt(i + 1)
-	if len(parent.keys) == 0 {
-		first.parent = nil
-		parent = nil
-		return first, true
+	n.keys.truncate(0)
+	n.children.truncate(0)
+	n.parent, n.next, n.prev = nil, nil, nil
+}
+
+// findLeaf returns the leaf of the subtree rooted at this node that the
+// given key belongs to.
+func (n *node[kT, vT]) findLeaf(key kT, less LessFunc[kT]) *node[kT, vT] {
+	for !n.isLeaf {
+		n = n.children[n.childIndex(key, less)]
 	}
-	stopAt, _ := parent.mayMergeWithNeighbor(key, less)
-	return stopAt, true
+	return n
 }
 
-func (n *node[kT, vT]) print(w io.Writer) error {
+func (n *node[kT, vT]) leftmostLeaf() *node[kT, vT] {
+	for !n.isLeaf {
+		n = n.children[0]
+	}
+	return n
+}
+
+func (n *node[kT, vT]) rightmostLeaf() *node[kT, vT] {
+	for !n.isLeaf {
+		n = n.children[len(n.children)-1]
+	}
+	return n
+}
+
+func (n *node[kT, vT]) print(w io.Writer, format func(k kT, v vT) string) error {
 	if n == nil {
 		return nil
 	}
@@ -308,7 +444,8 @@ func (n *node[kT, vT]) print(w io.Writer) error {
 			a := q.removeAt(0)
 			for i, key := range a.keys {
 				if a.isLeaf {
-					out.WriteString(fmt.Sprintf("%v-%v ", key, a.values[i]))
+					out.WriteString(format(key, a.values[i]))
+					out.WriteString(" ")
 					continue
 				}
 				out.WriteString(fmt.Sprintf("%v ", key))
@@ -329,43 +466,453 @@ func (n *node[kT, vT]) print(w io.Writer) error {
 type LessFunc[T any] func(a, b T) bool
 
 type BPlusTree[kT, vT any] struct {
-	order int
-	less  LessFunc[kT]
-	root  *node[kT, vT]
+	order  int
+	less   LessFunc[kT]
+	root   *node[kT, vT]
+	length int
+
+	bloom       *bloomConfig[kT]  // nil unless WithBloomFilter is given
+	slack       int               // see WithMergeHysteresis
+	store       LeafStore[kT, vT] // see WithLeafStore
+	appendSplit bool              // see WithAppendOptimizedSplit
+
+	resolve ConflictFunc[kT, vT] // nil unless WithOnConflict is given
+	checks  bool                 // see WithInvariantChecks
+
+	gen       uint64       // generation of the nodes not shared with a snapshot
+	snapshots atomic.Int32 // number of open snapshots, see SnapshotCursor
+}
+
+// New returns an empty tree of the given order, the maximum number of entries
+// of a leaf and of children of an internal node, ordering its keys with less.
+// It panics with the error of TryNew, wrapping ErrInvalidOrder, if order is
+// below 3.
+func New[kT, vT any](order int, less LessFunc[kT], opts ...Option[kT, vT]) *BPlusTree[kT, vT] {
+	t, err := TryNew(order, less, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TryNew is New returning an error wrapping ErrInvalidOrder, rather than
+// panicking, if order is below 3, e.g. for an order read from a config.
+func TryNew[kT, vT any](order int, less LessFunc[kT], opts ...Option[kT, vT]) (*BPlusTree[kT, vT], error) {
+	if order < 3 {
+		return nil, fmt.Errorf("%w: %d, expect at least 3", ErrInvalidOrder, order)
+	}
+	t := &BPlusTree[kT, vT]{order: order, less: less, store: SliceStore[kT, vT]{}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// WithMergeHysteresis lets a leaf drop up to slack keys below half-full
+// before a removal steals from a sibling or merges it, leaving a band
+// between the split and merge thresholds so that workloads churning
+// inserts and removals around a leaf boundary don't thrash between
+// splitting and merging. It is clamped so that a leaf keeps at least one
+// key, the default is no slack.
+func WithMergeHysteresis[kT, vT any](slack int) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		leaf := &node[kT, vT]{order: t.order, isLeaf: true}
+		if slack > leaf.minKeys()-1 {
+			slack = leaf.minKeys() - 1
+		}
+		if slack < 0 {
+			slack = 0
+		}
+		t.slack = slack
+	}
 }
 
-func New[kT, vT any](order int, less LessFunc[kT]) *BPlusTree[kT, vT] {
-	return &BPlusTree[kT, vT]{order: order, less: less}
+// WithAppendOptimizedSplit makes an insertion of the largest key of the tree
+// that overflows the last leaf split it so that the leaf stays full and the
+// new key moves alone into a new last leaf. Keys inserted in increasing
+// order then fill every leaf but the last one, rather than leaving each at
+// half-full as the default middle split does, which suits append-mostly
+// workloads, e.g. time series or sequence numbers. The last leaf is exempt
+// from the minimum fill then, other leaves and internal nodes split as usual.
+func WithAppendOptimizedSplit[kT, vT any](enabled bool) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		t.appendSplit = enabled
+	}
 }
 
+// ConflictFunc returns the value to keep under a key inserted while it
+// already exists, given the existing and the incoming values.
+type ConflictFunc[kT, vT any] func(key kT, existing, incoming vT) vT
+
+// WithOnConflict makes Insert, and the writes built on it, store the value
+// resolve returns when the key exists already, which lets callers merge
+// values, e.g. keep the max, sum or append, without a read-modify-write of
+// their own. By default the incoming value replaces the existing one.
+func WithOnConflict[kT, vT any](resolve ConflictFunc[kT, vT]) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		t.resolve = resolve
+	}
+}
+
+// Insert stores the value under the given key. It returns true if the key
+// existed already and its value got overwritten, and false if the key is
+// new, the first key of an empty tree included.
 func (t *BPlusTree[kT, vT]) Insert(key kT, value vT) bool {
+	replaced, _ := t.InsertTracked(key, value)
+	return replaced
+}
+
+// InsertTracked is Insert also reporting how many nodes the insertion split:
+// 0 if the entry fit in its leaf, 1 for a leaf split, and one more for each
+// ancestor the splits cascaded to, the root included.
+func (t *BPlusTree[kT, vT]) InsertTracked(key kT, value vT) (replaced bool, splits int) {
+	return t.insert(key, value, t.resolve)
+}
+
+// insert is InsertTracked resolving a conflict with resolve rather than the
+// resolver of the tree, nil storing the given value as is.
+func (t *BPlusTree[kT, vT]) insert(key kT, value vT, resolve ConflictFunc[kT, vT]) (replaced bool, splits int) {
 	if t.root == nil {
-		t.root = &node[kT, vT]{order: t.order, isLeaf: true}
+		t.root = t.newLeaf()
 		t.root.keys = append(t.root.keys, key)
 		t.root.values = append(t.root.values, value)
-		return false
+		t.root.bloomAdd(key)
+		t.length++
+		t.check("Insert")
+		return false, 0
 	}
-	root, found := t.root.insert(key, value, t.less)
+	root, replaced, splits := t.own(key, false).insertIntoLeaf(key, value, t.less, resolve)
 	if root != nil {
 		t.root = root
 	}
-	return found
+	if !replaced {
+		t.length++
+	}
+	t.check("Insert")
+	return replaced, splits
+}
+
+// InsertAll inserts the pairs in the given order and returns a parallel
+// slice reporting, for each pair, whether it replaced an existing value. A
+// key repeated within the batch replaces the value inserted earlier in it.
+func (t *BPlusTree[kT, vT]) InsertAll(pairs []Pair[kT, vT]) []bool {
+	replaced := make([]bool, len(pairs))
+	for i, pair := range pairs {
+		replaced[i] = t.Insert(pair.Key, pair.Value)
+	}
+	return replaced
 }
 
 func (t *BPlusTree[kT, vT]) Remove(key kT) (_ vT, _ bool) {
 	if t.root == nil {
 		return
 	}
-	stopNode, out, found := t.root.remove(key, t.less)
-	if stopNode != nil && stopNode.parent == nil {
-		t.root = stopNode
+	root, out, found := t.own(key, true).removeFromLeaf(key, t.less)
+	if !found {
+		return
+	}
+	t.removed(root)
+	return out, true
+}
+
+// RemoveMin removes and returns the entry with the smallest key by removing
+// the first entry of the leftmost leaf directly, or returns false if the
+// tree is empty.
+func (t *BPlusTree[kT, vT]) RemoveMin() (_ kT, _ vT, _ bool) {
+	if t.root == nil {
+		return
+	}
+	root, key, value := t.ownLeaf(t.root.leftmostLeaf()).removeEntry(0)
+	t.removed(root)
+	return key, value, true
+}
+
+// RemoveMax removes and returns the entry with the largest key by removing
+// the last entry of the rightmost leaf directly, or returns false if the
+// tree is empty.
+func (t *BPlusTree[kT, vT]) RemoveMax() (_ kT, _ vT, _ bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.ownLeaf(t.root.rightmostLeaf())
+	root, key, value := n.removeEntry(len(n.keys) - 1)
+	t.removed(root)
+	return key, value, true
+}
+
+// DeletePrefix removes the consecutive entries starting at the first key
+// greater than or equal to lo for which belongs returns true, it stops at
+// the first key that doesn't belong and returns the number of entries
+// removed. For keys where a prefix maps to a contiguous range, e.g. paths,
+// this removes the whole range without computing its upper bound. The
+// entries are removed one by one, each costing a descent and a rebalance.
+func (t *BPlusTree[kT, vT]) DeletePrefix(belongs func(kT) bool, lo kT) int {
+	cnt := 0
+	for {
+		leaf, index := t.seek(lo)
+		if leaf == nil || !belongs(leaf.keys[index]) {
+			return cnt
+		}
+		root, key, _ := t.ownLeaf(leaf).removeEntry(index)
+		t.removed(root)
+		lo = key
+		cnt++
+	}
+}
+
+// RemoveAll removes the given keys, skipping the absent ones, and returns
+// the number of entries removed. The keys are sorted once and removed in
+// descending order, walking the leaf chain backwards and re-descending from
+// the root only when the next key is before the neighbouring leaf or a
+// removal rebalanced the leaf.
+func (t *BPlusTree[kT, vT]) RemoveAll(keys []kT) int {
+	sorted := append([]kT(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return t.less(sorted[j], sorted[i])
+	})
+
+	cnt := 0
+	var n *node[kT, vT]
+	for _, key := range sorted {
+		if t.root == nil {
+			break
+		}
+		if n != nil && t.less(key, n.keys[0]) {
+			n = n.prev
+			if n != nil && t.less(key, n.keys[0]) {
+				n = nil
+			}
+		}
+		if n == nil {
+			n = t.root.findLeaf(key, t.less)
+		}
+		index, found := n.keys.find(key, t.less)
+		if !found {
+			continue
+		}
+		// A removal leaving the leaf underflowed may steal from or merge
+		// it with a sibling, the leaf has to be looked up again then.
+		rebalanced := n.parent != nil && len(n.keys)-1 < n.mergeKeys()
+		n = t.ownLeaf(n)
+		root, _, _ := n.removeEntry(index)
+		t.removed(root)
+		cnt++
+		if rebalanced || t.root == nil {
+			n = nil
+		}
+	}
+	return cnt
+}
+
+// removed updates the tree after one entry got removed, root is the new
+// root if the removal changed it.
+func (t *BPlusTree[kT, vT]) removed(root *node[kT, vT]) {
+	if root != nil {
+		t.root = root
+	}
+	t.length--
+	if t.length == 0 {
+		t.root.free()
+		t.root = nil
+	}
+	t.check("removal")
+}
+
+// Clear removes all entries, the tree keeps its order and options and can be
+// refilled.
+func (t *BPlusTree[kT, vT]) Clear() {
+	t.freeLeaves()
+	t.root, t.length = nil, 0
+}
+
+// Order returns the branching factor the tree was created with.
+func (t *BPlusTree[kT, vT]) Order() int {
+	return t.order
+}
+
+// Get returns the value stored under the given key, and whether the key
+// exists in the tree.
+func (t *BPlusTree[kT, vT]) Get(key kT) (_ vT, _ bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.root.findLeaf(key, t.less)
+	if !n.mayContain(key) {
+		return
+	}
+	index, found := n.keys.find(key, t.less)
+	if !found {
+		return
+	}
+	return n.values[index], true
+}
+
+// GetOrDefault returns the value stored under the given key, or def if the
+// key is absent.
+func (t *BPlusTree[kT, vT]) GetOrDefault(key kT, def vT) vT {
+	if value, ok := t.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// MultiGet looks up all the given keys in one ordered pass, it returns the
+// values and found flags aligned with the input keys. The keys are sorted
+// once and resolved by walking the leaf chain, re-descending from the root
+// only when the next key is beyond the neighbouring leaf.
+func (t *BPlusTree[kT, vT]) MultiGet(keys []kT) ([]vT, []bool) {
+	values := make([]vT, len(keys))
+	found := make([]bool, len(keys))
+	if t.root == nil || len(keys) == 0 {
+		return values, found
+	}
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return t.less(keys[order[i]], keys[order[j]])
+	})
+
+	var n *node[kT, vT]
+	for _, i := range order {
+		key := keys[i]
+		if n != nil && t.less(n.keys[len(n.keys)-1], key) {
+			n = n.next
+			if n != nil && t.less(n.keys[len(n.keys)-1], key) {
+				n = nil
+			}
+		}
+		if n == nil {
+			n = t.root.findLeaf(key, t.less)
+		}
+		index, ok := n.keys.find(key, t.less)
+		if ok {
+			values[i], found[i] = n.values[index], true
+		}
 	}
-	return out, found
+	return values, found
+}
+
+// Len returns the number of key-value pairs in the tree.
+func (t *BPlusTree[kT, vT]) Len() int {
+	return t.length
+}
+
+// Min returns the entry with the smallest key, or false if the tree is empty.
+func (t *BPlusTree[kT, vT]) Min() (_ kT, _ vT, _ bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.root.leftmostLeaf()
+	return n.keys[0], n.values[0], true
+}
+
+// Max returns the entry with the largest key, or false if the tree is empty.
+func (t *BPlusTree[kT, vT]) Max() (_ kT, _ vT, _ bool) {
+	if t.root == nil {
+		return
+	}
+	n := t.root.rightmostLeaf()
+	last := len(n.keys) - 1
+	return n.keys[last], n.values[last], true
+}
+
+// First returns the leftmost entry of the tree, or false if it is empty.
+func (t *BPlusTree[kT, vT]) First() (kT, vT, bool) {
+	return t.Min()
+}
+
+// Last returns the rightmost entry of the tree, or false if it is empty.
+func (t *BPlusTree[kT, vT]) Last() (kT, vT, bool) {
+	return t.Max()
+}
+
+// Ascend calls the iterator for every entry in the tree in ascending key
+// order by walking the leaf chain, until the iterator returns false.
+func (t *BPlusTree[kT, vT]) Ascend(iter func(key kT, value vT) bool) {
+	if t.root == nil {
+		return
+	}
+	for n := t.root.leftmostLeaf(); n != nil; n = n.next {
+		for i, key := range n.keys {
+			if !iter(key, n.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// AscendKeys calls the iterator for every key in the tree in ascending order
+// until the iterator returns false, like Ascend but without touching the
+// values.
+func (t *BPlusTree[kT, vT]) AscendKeys(iter func(key kT) bool) {
+	if t.root == nil {
+		return
+	}
+	for n := t.root.leftmostLeaf(); n != nil; n = n.next {
+		for _, key := range n.keys {
+			if !iter(key) {
+				return
+			}
+		}
+	}
+}
+
+// AscendFrom calls the iterator in ascending key order for the entries from
+// start to the end of the tree until the iterator returns false. The entry
+// of start, if any, is included if inclusive is true: an exclusive start
+// resumes a scan after the last key seen without visiting it again.
+func (t *BPlusTree[kT, vT]) AscendFrom(start kT, inclusive bool, iter func(key kT, value vT) bool) {
+	c := t.LowerBound(start)
+	if !inclusive {
+		c = t.UpperBound(start)
+	}
+	for ; c.Valid(); c.Next() {
+		if !iter(c.Key(), c.Value()) {
+			return
+		}
+	}
+}
+
+// AscendErr calls the iterator for every entry in the tree in ascending key
+// order, like Ascend, until the iterator returns an error which is then
+// returned as is.
+func (t *BPlusTree[kT, vT]) AscendErr(iter func(key kT, value vT) error) (err error) {
+	t.Ascend(func(key kT, value vT) bool {
+		err = iter(key, value)
+		return err == nil
+	})
+	return
+}
+
+// FindByValue returns, in ascending order, the keys whose values satisfy
+// match. Values are not indexed, so this scans every entry of the tree and
+// costs O(n) regardless of how many keys match.
+func (t *BPlusTree[kT, vT]) FindByValue(match func(vT) bool) []kT {
+	var out []kT
+	t.Ascend(func(key kT, value vT) bool {
+		if match(value) {
+			out = append(out, key)
+		}
+		return true
+	})
+	return out
+}
+
+// Print writes the tree level by level, one line per level, rendering the
+// entries of the leaves as key-value.
+func (t *BPlusTree[kT, vT]) Print(w io.Writer) error {
+	return t.PrintFunc(w, func(k kT, v vT) string {
+		return fmt.Sprintf("%v-%v", k, v)
+	})
 }
 
-func (t *BPlusTree[kt, vT]) Print(w io.Writer) error {
+// PrintFunc is Print rendering each entry of the leaves with format, e.g. to
+// show an ID rather than a whole struct value.
+func (t *BPlusTree[kT, vT]) PrintFunc(w io.Writer, format func(k kT, v vT) string) error {
 	if t.root == nil {
 		return nil
 	}
-	return t.root.print(w)
+	return t.root.print(w, format)
 }
@@ -1,9 +1,17 @@
 package bplustree
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestSortSearch(t *testing.T) {
@@ -29,3 +37,909 @@ func TestSortSearch(t *testing.T) {
 		})
 	}
 }
+
+func intLess(a, b int) bool { return a < b }
+
+// newIntTree returns a tree of the given order holding the given keys,
+// each key maps to its value multiplied by 10.
+func newIntTree(order int, keys ...int) *BPlusTree[int, int] {
+	tree := New[int, int](order, intLess)
+	for _, key := range keys {
+		tree.Insert(key, key*10)
+	}
+	return tree
+}
+
+// seq returns the integers in [lo, hi) with the given step.
+func seq(lo, hi, step int) []int {
+	var out []int
+	for i := lo; i < hi; i += step {
+		out = append(out, i)
+	}
+	return out
+}
+
+func TestFirstLast(t *testing.T) {
+	tree := New[int, int](3, intLess)
+	if _, _, ok := tree.First(); ok {
+		t.Fatalf("expect no first entry in an empty tree")
+	}
+	if _, _, ok := tree.Last(); ok {
+		t.Fatalf("expect no last entry in an empty tree")
+	}
+	for _, key := range []int{5, 3, 9, 1, 7} {
+		tree.Insert(key, key*10)
+	}
+	if key, value, ok := tree.First(); !ok || key != 1 || value != 10 {
+		t.Fatalf("expect first 1-10, got %d-%d", key, value)
+	}
+	if key, value, ok := tree.Last(); !ok || key != 9 || value != 90 {
+		t.Fatalf("expect last 9-90, got %d-%d", key, value)
+	}
+}
+
+func TestGetOrDefault(t *testing.T) {
+	tree := newIntTree(4, seq(0, 20, 2)...)
+	if got := tree.GetOrDefault(4, -1); got != 40 {
+		t.Fatalf("expect the stored 40, got %d", got)
+	}
+	tree.Insert(6, 0)
+	if got := tree.GetOrDefault(6, -1); got != 0 {
+		t.Fatalf("expect a stored zero value, got %d", got)
+	}
+	if got := tree.GetOrDefault(5, -1); got != -1 {
+		t.Fatalf("expect the default for an absent key, got %d", got)
+	}
+	if got := New[int, int](4, intLess).GetOrDefault(5, 7); got != 7 {
+		t.Fatalf("expect the default on an empty tree, got %d", got)
+	}
+}
+
+func TestInvalidOrder(t *testing.T) {
+	for _, order := range []int{-1, 0, 1, 2} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidOrder) {
+					t.Fatalf("order %d: expect a panic with ErrInvalidOrder, got %v", order, err)
+				}
+			}()
+			New[int, int](order, intLess)
+		}()
+		if tree, err := TryNew[int, int](order, intLess); tree != nil || !errors.Is(err, ErrInvalidOrder) {
+			t.Fatalf("order %d: expect TryNew to fail with ErrInvalidOrder, got %v", order, err)
+		}
+	}
+	New[int, int](3, intLess)
+	if tree, err := TryNew[int, int](3, intLess); tree == nil || err != nil {
+		t.Fatalf("expect TryNew to accept order 3, got %v", err)
+	}
+}
+
+func TestMultiGet(t *testing.T) {
+	tree := newIntTree(4, seq(0, 200, 2)...)
+	keys := []int{150, 3, 4, 198, 4, -1, 0, 77, 100, 500, 102}
+	values, found := tree.MultiGet(keys)
+	for i, key := range keys {
+		value, ok := tree.Get(key)
+		if found[i] != ok || values[i] != value {
+			t.Fatalf("key %d: expect %d-%v, got %d-%v", key, value, ok, values[i], found[i])
+		}
+	}
+
+	values, found = New[int, int](4, intLess).MultiGet(keys)
+	for i := range keys {
+		if found[i] || values[i] != 0 {
+			t.Fatalf("expect nothing found in an empty tree")
+		}
+	}
+}
+
+func TestOnConflict(t *testing.T) {
+	maxOf := func(_ int, existing, incoming int) int {
+		if incoming > existing {
+			return incoming
+		}
+		return existing
+	}
+	sum := func(_ int, existing, incoming int) int { return existing + incoming }
+	for name, c := range map[string]struct {
+		resolve ConflictFunc[int, int]
+		expect  int
+	}{
+		"max":     {resolve: maxOf, expect: 9},
+		"sum":     {resolve: sum, expect: 25},
+		"default": {expect: 4},
+	} {
+		var opts []Option[int, int]
+		if c.resolve != nil {
+			opts = append(opts, WithOnConflict[int, int](c.resolve))
+		}
+		tree := New[int, int](3, intLess, opts...)
+		for _, key := range seq(0, 20, 1) {
+			tree.Insert(key, 0)
+		}
+		for _, value := range []int{3, 9, 1, 8, 4} {
+			if !tree.Insert(7, value) {
+				t.Fatalf("%s: expect a replace of the existing key", name)
+			}
+		}
+		if got, _ := tree.Get(7); got != c.expect {
+			t.Fatalf("%s: expect %d, got %d", name, c.expect, got)
+		}
+		if got, _ := tree.Get(8); got != 0 || tree.Len() != 20 {
+			t.Fatalf("%s: expect the other keys untouched", name)
+		}
+	}
+}
+
+func TestInsertAll(t *testing.T) {
+	tree := newIntTree(3, seq(0, 20, 2)...)
+	pairs := []Pair[int, int]{
+		{Key: 4, Value: 1}, {Key: 5, Value: 2}, {Key: 30, Value: 3},
+		{Key: 5, Value: 4}, {Key: 18, Value: 5}, {Key: -1, Value: 6},
+	}
+	replaced := tree.InsertAll(pairs)
+	expect := []bool{true, false, false, true, true, false}
+	if !reflect.DeepEqual(replaced, expect) {
+		t.Fatalf("expect replaced %v, got %v", expect, replaced)
+	}
+	if tree.Len() != 13 {
+		t.Fatalf("expect 13 entries, got %d", tree.Len())
+	}
+	for key, value := range map[int]int{4: 1, 5: 4, 30: 3, 18: 5, -1: 6} {
+		if got, _ := tree.Get(key); got != value {
+			t.Fatalf("key %d: expect %d, got %d", key, value, got)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.InsertAll(nil); len(got) != 0 {
+		t.Fatalf("expect no flags for an empty batch, got %v", got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	store := newArenaStore(100, 5)
+	tree := New[int, int](4, intLess, WithLeafStore[int, int](store))
+	for _, key := range seq(0, 50, 1) {
+		tree.Insert(key, key)
+	}
+	tree.Clear()
+	if tree.Len() != 0 || tree.root != nil {
+		t.Fatalf("expect an empty tree, got %d entries", tree.Len())
+	}
+	if len(store.live) != 0 {
+		t.Fatalf("expect the leaves handed back to the store, %d live", len(store.live))
+	}
+	for _, key := range []int{3, 1, 2} {
+		if tree.Insert(key, key) {
+			t.Fatalf("expect %d newly inserted after a clear", key)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.check(tree); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keysOf(tree), []int{1, 2, 3}) {
+		t.Fatalf("expect the ordering kept, got %v", keysOf(tree))
+	}
+}
+
+func TestAscendKeys(t *testing.T) {
+	New[int, int](4, intLess).AscendKeys(func(int) bool {
+		t.Fatal("expect no key in an empty tree")
+		return false
+	})
+	tree := newIntTree(4, seq(0, 100, 3)...)
+	var keys []int
+	tree.AscendKeys(func(key int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !reflect.DeepEqual(keys, keysOf(tree)) {
+		t.Fatalf("expect %v, got %v", keysOf(tree), keys)
+	}
+	keys = keys[:0]
+	tree.AscendKeys(func(key int) bool {
+		keys = append(keys, key)
+		return len(keys) < 5
+	})
+	if !reflect.DeepEqual(keys, seq(0, 15, 3)) {
+		t.Fatalf("expect an early stop after 5 keys, got %v", keys)
+	}
+}
+
+func TestAscendFrom(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 3)...)
+	collect := func(start int, inclusive bool) []int {
+		var keys []int
+		tree.AscendFrom(start, inclusive, func(key, value int) bool {
+			if value != key*10 {
+				t.Fatalf("key %d: expect value %d, got %d", key, key*10, value)
+			}
+			keys = append(keys, key)
+			return true
+		})
+		return keys
+	}
+	for _, c := range []struct {
+		start     int
+		inclusive bool
+		expect    []int
+	}{
+		{start: 30, inclusive: true, expect: seq(30, 100, 3)},
+		{start: 30, inclusive: false, expect: seq(33, 100, 3)},
+		{start: 31, inclusive: true, expect: seq(33, 100, 3)},
+		{start: 31, inclusive: false, expect: seq(33, 100, 3)},
+		{start: -1, inclusive: false, expect: seq(0, 100, 3)},
+		{start: 99, inclusive: true, expect: []int{99}},
+		{start: 99, inclusive: false, expect: nil},
+	} {
+		if got := collect(c.start, c.inclusive); !reflect.DeepEqual(got, c.expect) {
+			t.Fatalf("start %d inclusive %v: expect %v, got %v", c.start, c.inclusive, c.expect, got)
+		}
+	}
+
+	// resume a paged scan after the last key seen
+	var pages [][]int
+	last, inclusive := 0, true
+	for {
+		var page []int
+		tree.AscendFrom(last, inclusive, func(key, _ int) bool {
+			page = append(page, key)
+			return len(page) < 10
+		})
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		last, inclusive = page[len(page)-1], false
+	}
+	var keys []int
+	for _, page := range pages {
+		keys = append(keys, page...)
+	}
+	if len(pages) != 4 || !reflect.DeepEqual(keys, keysOf(tree)) {
+		t.Fatalf("expect 4 pages covering every key once, got %v", pages)
+	}
+}
+
+func TestAscendErr(t *testing.T) {
+	tree := newIntTree(4, seq(0, 50, 1)...)
+	var keys []int
+	if err := tree.AscendErr(func(key, _ int) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil || !reflect.DeepEqual(keys, seq(0, 50, 1)) {
+		t.Fatalf("expect a full scan without error, got %v %v", keys, err)
+	}
+
+	errStop := errors.New("stop")
+	keys = keys[:0]
+	err := tree.AscendErr(func(key, _ int) error {
+		keys = append(keys, key)
+		if key == 20 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("expect the error of the iterator, got %v", err)
+	}
+	if !reflect.DeepEqual(keys, seq(0, 21, 1)) {
+		t.Fatalf("expect the scan to stop at the first error, got %v", keys)
+	}
+
+	if err := New[int, int](4, intLess).AscendErr(func(int, int) error { return errStop }); err != nil {
+		t.Fatalf("expect no error on an empty tree, got %v", err)
+	}
+}
+
+// keysOf returns the keys of the tree in ascending order.
+func keysOf[kT, vT any](tree *BPlusTree[kT, vT]) []kT {
+	var out []kT
+	tree.Ascend(func(key kT, _ vT) bool {
+		out = append(out, key)
+		return true
+	})
+	return out
+}
+
+func TestFindByValue(t *testing.T) {
+	tree := newIntTree(3, seq(0, 40, 1)...)
+	got := tree.FindByValue(func(value int) bool { return value%70 == 0 })
+	if !reflect.DeepEqual(got, []int{0, 7, 14, 21, 28, 35}) {
+		t.Fatalf("unexpected keys %v", got)
+	}
+	if got := tree.FindByValue(func(int) bool { return false }); len(got) != 0 {
+		t.Fatalf("expect no keys, got %v", got)
+	}
+}
+
+func TestRemoveMinMax(t *testing.T) {
+	for order := 3; order <= 6; order++ {
+		tree := newIntTree(order, seq(0, 300, 1)...)
+		for expect := 0; expect < 150; expect++ {
+			key, value, ok := tree.RemoveMin()
+			if !ok || key != expect || value != expect*10 {
+				t.Fatalf("order %d: expect min %d, got %d-%d-%v", order, expect, key, value, ok)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("order %d: %v after removing min %d", order, err, key)
+			}
+		}
+		for expect := 299; expect >= 150; expect-- {
+			key, value, ok := tree.RemoveMax()
+			if !ok || key != expect || value != expect*10 {
+				t.Fatalf("order %d: expect max %d, got %d-%d-%v", order, expect, key, value, ok)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("order %d: %v after removing max %d", order, err, key)
+			}
+		}
+		if _, _, ok := tree.RemoveMin(); ok {
+			t.Fatalf("order %d: expect nothing left to remove", order)
+		}
+		if _, _, ok := tree.RemoveMax(); ok {
+			t.Fatalf("order %d: expect nothing left to remove", order)
+		}
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	for order := 3; order <= 6; order++ {
+		tree := New[string, int](order, func(a, b string) bool { return a < b })
+		var expect []string
+		for _, dir := range []string{"a/", "b/", "ba/", "c/"} {
+			for i := 0; i < 40; i++ {
+				key := fmt.Sprintf("%s%02d", dir, i)
+				tree.Insert(key, i)
+				if dir != "b/" {
+					expect = append(expect, key)
+				}
+			}
+		}
+		under := func(prefix string) func(string) bool {
+			return func(key string) bool { return strings.HasPrefix(key, prefix) }
+		}
+		if n := tree.DeletePrefix(under("b/"), "b/"); n != 40 {
+			t.Fatalf("order %d: expect 40 entries removed, got %d", order, n)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("order %d: %v", order, err)
+		}
+		if got := keysOf(tree); !reflect.DeepEqual(got, expect) {
+			t.Fatalf("order %d: expect the removal to stop at ba/, got %v", order, got)
+		}
+		if n := tree.DeletePrefix(under("b/"), "b/"); n != 0 {
+			t.Fatalf("order %d: expect nothing left under b/, got %d removed", order, n)
+		}
+		// The keys from a/20 on don't belong to a/1, the removal stops at
+		// the first of them without looking further.
+		if n := tree.DeletePrefix(under("a/1"), "a/10"); n != 10 || tree.Len() != 110 {
+			t.Fatalf("order %d: expect 10 entries removed, got %d", order, n)
+		}
+		if n := tree.DeletePrefix(func(string) bool { return true }, ""); n != 110 || tree.Len() != 0 {
+			t.Fatalf("order %d: expect every entry removed, got %d", order, n)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("order %d: %v", order, err)
+		}
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	for _, order := range []int{3, 4, 7, 32} {
+		r := rand.New(rand.NewSource(int64(order)))
+		tree := newIntTree(order, seq(0, 2000, 2)...)
+		// Every key below 1000 goes, the odd ones are absent, along with a
+		// few keys past both ends and a repeated one.
+		keys := append(seq(0, 1000, 1), -5, 3000, 500, 500)
+		r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		if n := tree.RemoveAll(keys); n != 500 {
+			t.Fatalf("order %d: expect 500 entries removed, got %d", order, n)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("order %d: %v", order, err)
+		}
+		if got := keysOf(tree); !reflect.DeepEqual(got, seq(1000, 2000, 2)) {
+			t.Fatalf("order %d: unexpected keys left %v", order, got)
+		}
+		if n := tree.RemoveAll(seq(0, 2000, 1)); n != 500 || tree.Len() != 0 {
+			t.Fatalf("order %d: expect every entry removed, got %d", order, n)
+		}
+		if n := tree.RemoveAll([]int{1, 2}); n != 0 {
+			t.Fatalf("order %d: expect nothing removed from an empty tree", order)
+		}
+	}
+}
+
+func TestRemoveRandomized(t *testing.T) {
+	for _, order := range []int{3, 4, 7, 32} {
+		r := rand.New(rand.NewSource(int64(order)))
+		keys := r.Perm(20000)
+		tree := New[int, int](order, intLess)
+		for _, key := range keys {
+			tree.Insert(key, -key)
+		}
+		r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for i, key := range keys {
+			if value, ok := tree.Remove(key); !ok || value != -key {
+				t.Fatalf("order %d: remove %d expect %d, got %d-%v", order, key, -key, value, ok)
+			}
+			if _, ok := tree.Remove(key); ok {
+				t.Fatalf("order %d: expect %d removed already", order, key)
+			}
+			if i%500 == 0 {
+				if err := tree.Validate(); err != nil {
+					t.Fatalf("order %d: %v after %d removals", order, err, i+1)
+				}
+			}
+		}
+		if tree.Len() != 0 || tree.root != nil {
+			t.Fatalf("order %d: expect an empty tree, got %d entries", order, tree.Len())
+		}
+	}
+}
+
+func TestInvariantChecks(t *testing.T) {
+	tree := New[int, int](4, intLess, WithInvariantChecks[int, int](true))
+	r := rand.New(rand.NewSource(29))
+	for i := 0; i < 2000; i++ {
+		key := r.Intn(300)
+		if r.Intn(3) == 0 {
+			tree.Remove(key)
+		} else {
+			tree.Insert(key, key)
+		}
+	}
+
+	tree.length++ // corrupt the length counter
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "invariant violated after Insert") {
+			t.Fatalf("expect a panic on the corrupted tree, got %q", msg)
+		}
+	}()
+	tree.Insert(1000, 1000)
+}
+
+func TestInsertReplaced(t *testing.T) {
+	tree := New[int, int](3, intLess)
+	if tree.Insert(1, 10) {
+		t.Fatal("expect the first insert not to replace")
+	}
+	for key := 2; key < 50; key++ {
+		if tree.Insert(key, key*10) {
+			t.Fatalf("expect the new key %d not to replace", key)
+		}
+	}
+	for _, key := range []int{1, 25, 49} {
+		if !tree.Insert(key, -key) {
+			t.Fatalf("expect the duplicate key %d to replace", key)
+		}
+		if value, _ := tree.Get(key); value != -key {
+			t.Fatalf("expect key %d overwritten with %d, got %d", key, -key, value)
+		}
+	}
+	if tree.Len() != 49 {
+		t.Fatalf("expect 49 entries, got %d", tree.Len())
+	}
+}
+
+func TestInsertTracked(t *testing.T) {
+	tree := New[int, int](3, intLess)
+	for key := 1; key <= 3; key++ {
+		if _, splits := tree.InsertTracked(key, key); splits != 0 {
+			t.Fatalf("key %d: expect no split, got %d", key, splits)
+		}
+	}
+	if _, splits := tree.InsertTracked(4, 4); splits != 1 || tree.Stats().Height != 2 {
+		t.Fatalf("expect the leaf root to split once, got %d splits", splits)
+	}
+	// Fill the tree until the internal root overflows, which splits a leaf
+	// and the root.
+	key := 5
+	for ; tree.Stats().Height == 2; key++ {
+		if _, splits := tree.InsertTracked(key, key); tree.Stats().Height == 3 && splits != 2 {
+			t.Fatalf("expect a leaf and the root to split, got %d splits", splits)
+		}
+	}
+	if replaced, splits := tree.InsertTracked(1, 10); !replaced || splits != 0 {
+		t.Fatalf("expect a replacement without split, got %v %d", replaced, splits)
+	}
+
+	// Each split adds a node, and a split of the root adds the new root.
+	r := rand.New(rand.NewSource(37))
+	tree = New[int, int](4, intLess)
+	for i := 0; i < 3000; i++ {
+		before := tree.Stats()
+		_, splits := tree.InsertTracked(r.Intn(5000), i)
+		after := tree.Stats()
+		grown := 0
+		if before.Height > 0 && after.Height > before.Height {
+			grown = 1
+		}
+		if nodes := after.Leaves + after.Internals - before.Leaves - before.Internals; before.Height > 0 && nodes != splits+grown {
+			t.Fatalf("step %d: expect %d splits, got %d", i, nodes-grown, splits)
+		}
+	}
+}
+
+func TestCapacityRules(t *testing.T) {
+	for order := 3; order <= 16; order++ {
+		leaf := &node[int, int]{order: order, isLeaf: true}
+		internal := &node[int, int]{order: order}
+		half := (order + 1) / 2
+		if leaf.minKeys() != half || leaf.maxKeys() != order {
+			t.Fatalf("order %d: expect a leaf to hold [%d, %d] keys, got [%d, %d]", order, half, order, leaf.minKeys(), leaf.maxKeys())
+		}
+		if internal.minKeys() != half-1 || internal.maxKeys() != order-1 {
+			t.Fatalf("order %d: expect an internal node to hold [%d, %d] keys, got [%d, %d]", order, half-1, order-1, internal.minKeys(), internal.maxKeys())
+		}
+		// A leaf splits with order+1 entries at minKeys, keeping the
+		// entries on both sides.
+		if l, r := leaf.minKeys(), order+1-leaf.minKeys(); l < leaf.minKeys() || r < leaf.minKeys() || r > leaf.maxKeys() {
+			t.Fatalf("order %d: leaf split into %d and %d entries", order, l, r)
+		}
+		// An internal node splits with order keys at minKeys, promoting
+		// the key at the split index.
+		if l, r := internal.minKeys(), order-1-internal.minKeys(); l < internal.minKeys() || r < internal.minKeys() || r > internal.maxKeys() {
+			t.Fatalf("order %d: internal split into %d and %d keys", order, l, r)
+		}
+		// A node one below the minimum merges with a sibling at the
+		// minimum, pulling the separator down for an internal node.
+		if merged := leaf.minKeys() - 1 + leaf.minKeys(); merged > leaf.maxKeys() {
+			t.Fatalf("order %d: merged leaf of %d entries overflows", order, merged)
+		}
+		if merged := internal.minKeys() - 1 + internal.minKeys() + 1; merged > internal.maxKeys() {
+			t.Fatalf("order %d: merged internal node of %d keys overflows", order, merged)
+		}
+	}
+}
+
+// TestMinFill runs random mutations on trees of even and odd orders with
+// invariant checks enabled, so every non-root node is checked to be within
+// its bounds after each operation.
+func TestMinFill(t *testing.T) {
+	for order := 3; order <= 10; order++ {
+		for _, slack := range []int{0, 1} {
+			tree := New[int, int](order, intLess, WithInvariantChecks[int, int](true), WithMergeHysteresis[int, int](slack))
+			r := rand.New(rand.NewSource(int64(order*10 + slack)))
+			for i := 0; i < 3000; i++ {
+				key := r.Intn(400)
+				switch r.Intn(8) {
+				case 0, 1, 2, 3:
+					tree.Insert(key, i)
+				case 4, 5:
+					tree.Remove(key)
+				case 6:
+					tree.RemoveMin()
+				case 7:
+					tree.RemoveMax()
+				}
+			}
+			for tree.Len() > 0 {
+				tree.Remove(r.Intn(400))
+			}
+		}
+	}
+}
+
+func TestMergeHysteresis(t *testing.T) {
+	// sequential inserts leave most leaves at exactly half-full.
+	plain := newIntTree(8, seq(0, 200, 1)...)
+	tree := New[int, int](8, intLess, WithMergeHysteresis[int, int](2))
+	for _, key := range seq(0, 200, 1) {
+		tree.Insert(key, key*10)
+	}
+	leaves := tree.Stats().Leaves
+	for _, key := range seq(0, 200, 5) {
+		plain.Remove(key)
+		tree.Remove(key)
+	}
+	if got := tree.Stats().Leaves; got != leaves {
+		t.Fatalf("expect the slack to absorb one removal per leaf, leaves %d -> %d", leaves, got)
+	}
+	if got := plain.Stats().Leaves; got >= leaves {
+		t.Fatalf("expect the default tree to merge leaves, leaves %d -> %d", leaves, got)
+	}
+	if !reflect.DeepEqual(keysOf(tree), keysOf(plain)) {
+		t.Fatal("expect both trees to hold the same keys")
+	}
+
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(300)
+		if r.Intn(2) == 0 {
+			tree.Insert(key, key)
+		} else {
+			tree.Remove(key)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("op %d: %v", i, err)
+		}
+	}
+
+	// the slack is clamped so a leaf keeps at least one key.
+	if tree := New[int, int](4, intLess, WithMergeHysteresis[int, int](10)); tree.slack != 1 {
+		t.Fatalf("expect the slack clamped to 1, got %d", tree.slack)
+	}
+}
+
+// benchmarkChurn removes and reinserts random keys of a tree whose leaves
+// are mostly half-full, reporting how many of the operations stole, merged
+// or split leaves.
+func benchmarkChurn(b *testing.B, opts ...Option[int, int]) {
+	tree := New[int, int](16, intLess, opts...)
+	for _, key := range seq(0, 100000, 1) {
+		tree.Insert(key, key)
+	}
+	keys := rand.New(rand.NewSource(1)).Perm(100000)
+	restructures := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keys[i%len(keys)]
+		leaf := tree.root.findLeaf(key, intLess)
+		cnt := len(leaf.keys)
+		tree.Remove(key)
+		if after := tree.root.findLeaf(key, intLess); after != leaf || len(after.keys) != cnt-1 {
+			restructures++
+		}
+		leaf = tree.root.findLeaf(key, intLess)
+		cnt = len(leaf.keys)
+		tree.Insert(key, key)
+		if after := tree.root.findLeaf(key, intLess); after != leaf || len(after.keys) != cnt+1 {
+			restructures++
+		}
+	}
+	b.ReportMetric(float64(restructures)/float64(2*b.N), "restructures/op")
+}
+
+func BenchmarkChurn(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchmarkChurn(b)
+	})
+	b.Run("hysteresis", func(b *testing.B) {
+		benchmarkChurn(b, WithMergeHysteresis[int, int](3))
+	})
+}
+
+// mustPanic runs fn and fails unless it panics.
+func mustPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("%s: expect the panic to propagate", name)
+		}
+	}()
+	fn()
+}
+
+func TestPanickingCallbacks(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 1)...)
+	other := newIntTree(4, seq(50, 150, 1)...)
+	expect := keysOf(tree)
+	calls := map[string]func(){
+		"Ascend": func() {
+			tree.Ascend(func(key, _ int) bool {
+				if key == 42 {
+					panic("boom")
+				}
+				return true
+			})
+		},
+		"FindByValue": func() {
+			tree.FindByValue(func(value int) bool { panic("boom") })
+		},
+		"WalkNodes": func() {
+			tree.WalkNodes(func(depth int, _ bool, _ []int) bool {
+				if depth > 0 {
+					panic("boom")
+				}
+				return true
+			})
+		},
+		"Diff": func() {
+			tree.Diff(other, func(a, b int) bool { panic("boom") })
+		},
+		"Transaction": func() {
+			tree.Transaction(func(tx *Tx[int, int]) error {
+				tx.Insert(500, 1)
+				tx.Remove(7)
+				panic("boom")
+			})
+		},
+	}
+	for name, call := range calls {
+		mustPanic(t, name, call)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%s: tree corrupted after a panic: %v", name, err)
+		}
+		if !reflect.DeepEqual(keysOf(tree), expect) {
+			t.Fatalf("%s: tree changed after a panic", name)
+		}
+	}
+}
+
+func TestRemovalReleasesMemory(t *testing.T) {
+	type payload struct{ data [1 << 10]byte }
+	var valuesFreed, nodesFreed int32
+	tree := New[int, *payload](4, intLess)
+	for _, key := range seq(0, 1000, 1) {
+		p := &payload{}
+		runtime.SetFinalizer(p, func(*payload) { atomic.AddInt32(&valuesFreed, 1) })
+		tree.Insert(key, p)
+	}
+	countNodes := func() int {
+		cnt := 0
+		tree.WalkNodes(func(int, bool, []int) bool {
+			cnt++
+			return true
+		})
+		return cnt
+	}
+	var track func(n *node[int, *payload])
+	track = func(n *node[int, *payload]) {
+		runtime.SetFinalizer(n, func(*node[int, *payload]) { atomic.AddInt32(&nodesFreed, 1) })
+		for _, child := range n.children {
+			track(child)
+		}
+	}
+	track(tree.root)
+	before := countNodes()
+
+	// remove most keys, in an order merging both leaves and internal nodes.
+	r := rand.New(rand.NewSource(10))
+	for _, key := range r.Perm(1000)[:950] {
+		tree.Remove(key)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	dropped := before - countNodes()
+
+	for i := 0; i < 50 && (atomic.LoadInt32(&valuesFreed) < 950 || int(atomic.LoadInt32(&nodesFreed)) < dropped); i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&valuesFreed); got != 950 {
+		t.Fatalf("expect the 950 removed values collected, got %d", got)
+	}
+	if got := atomic.LoadInt32(&nodesFreed); int(got) != dropped {
+		t.Fatalf("expect the %d dropped nodes collected, got %d", dropped, got)
+	}
+	runtime.KeepAlive(tree)
+}
+
+func TestPrintFunc(t *testing.T) {
+	tree := newIntTree(3, 1, 2, 3, 4)
+	var out strings.Builder
+	if err := tree.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "| 3 |\n| 1-10 2-20 || 3-30 4-40 |\n"; out.String() != expect {
+		t.Fatalf("expect %q, got %q", expect, out.String())
+	}
+
+	tree = newIntTree(4, seq(0, 50, 1)...)
+	out.Reset()
+	err := tree.PrintFunc(&out, func(k, v int) string {
+		return fmt.Sprintf("#%d", v/10)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	leaves := lines[len(lines)-1]
+	for key := 0; key < 50; key++ {
+		if !strings.Contains(leaves, fmt.Sprintf("#%d ", key)) {
+			t.Fatalf("expect entry %d formatted in %q", key, leaves)
+		}
+	}
+	if strings.Contains(leaves, "-") {
+		t.Fatalf("expect no default formatting in %q", leaves)
+	}
+}
+
+func TestFind(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 8, 16, 17, 100} {
+		s := items[int](seq(0, 2*size, 2))
+		for item := -1; item <= 2*size; item++ {
+			index, found := s.find(item, intLess)
+			expect := sort.SearchInts(s, item)
+			if index != expect || found != (expect < len(s) && s[expect] == item) {
+				t.Fatalf("size %d: find %d expect %d, got %d-%v", size, item, expect, index, found)
+			}
+			if li, lf := findLinear(s, item, intLess); li != index || lf != found {
+				t.Fatalf("size %d: linear find %d expect %d-%v, got %d-%v", size, item, index, found, li, lf)
+			}
+		}
+	}
+}
+
+// benchLess is intLess behind a variable, so that the benchmarks call it
+// indirectly like a tree calls its less function.
+var benchLess LessFunc[int] = intLess
+
+// findLinear is items.find scanning the items linearly rather than binary
+// searching them, kept to compare both in BenchmarkFind.
+func findLinear[T any](s items[T], item T, less func(T, T) bool) (index int, found bool) {
+	i := 0
+	for i < len(s) && !less(item, s[i]) {
+		i++
+	}
+	if i > 0 && !less(s[i-1], item) {
+		return i - 1, true
+	}
+	return i, false
+}
+
+// BenchmarkFind compares the binary search of items.find with the linear scan
+// of findLinear on nodes of increasing order.
+func BenchmarkFind(b *testing.B) {
+	for _, order := range []int{4, 16, 64, 256} {
+		s := items[int](seq(0, 2*order, 2))
+		targets := rand.New(rand.NewSource(int64(order))).Perm(2 * order)
+		b.Run(fmt.Sprintf("binary/order=%d", order), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.find(targets[i%len(targets)], benchLess)
+			}
+		})
+		b.Run(fmt.Sprintf("linear/order=%d", order), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				findLinear(s, targets[i%len(targets)], benchLess)
+			}
+		})
+	}
+}
+
+func TestAppendOptimizedSplit(t *testing.T) {
+	for _, order := range []int{3, 4, 7, 32} {
+		tree := New[int, int](order, intLess, WithAppendOptimizedSplit[int, int](true))
+		for _, key := range seq(0, 1000, 1) {
+			tree.Insert(key, key)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("order %d: %v", order, err)
+		}
+		// Every leaf but the last one is full.
+		if stats := tree.Stats(); stats.Leaves != (1000+order-1)/order {
+			t.Fatalf("order %d: expect %d full leaves, got %+v", order, (1000+order-1)/order, stats)
+		}
+		// Out of order inserts and removals keep the tree valid.
+		checked := New[int, int](order, intLess,
+			WithAppendOptimizedSplit[int, int](true), WithInvariantChecks[int, int](true))
+		r := rand.New(rand.NewSource(int64(order)))
+		for i := 0; i < 3000; i++ {
+			if r.Intn(4) == 0 {
+				checked.Remove(r.Intn(i + 1))
+			} else if r.Intn(3) == 0 {
+				checked.Insert(r.Intn(i+1), i)
+			} else {
+				checked.Insert(i, i)
+			}
+		}
+	}
+}
+
+func BenchmarkAppendOptimizedSplit(b *testing.B) {
+	for _, enabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("enabled=%v", enabled), func(b *testing.B) {
+			var stats Stats
+			for i := 0; i < b.N; i++ {
+				tree := New[int, int](64, intLess, WithAppendOptimizedSplit[int, int](enabled))
+				for key := 0; key < 1000000; key++ {
+					tree.Insert(key, key)
+				}
+				stats = tree.Stats()
+			}
+			b.ReportMetric(float64(stats.Leaves), "leaves")
+			b.ReportMetric(float64(stats.Height), "height")
+		})
+	}
+}
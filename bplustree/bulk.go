@@ -0,0 +1,109 @@
+package bplustree
+
+import "fmt"
+
+// Pair is a key-value entry of the tree.
+type Pair[kT, vT any] struct {
+	Key   kT
+	Value vT
+}
+
+// BulkLoad replaces the content of the tree with the given pairs, which must
+// be sorted by key in strictly ascending order, otherwise it returns an
+// error wrapping ErrSortedInputRequired and leaves the tree untouched. The
+// tree is built bottom-up with leaves filled as evenly and densely as the
+// order allows, which is much cheaper than inserting the pairs one by one.
+func (t *BPlusTree[kT, vT]) BulkLoad(pairs []Pair[kT, vT]) error {
+	if i := t.unsortedAt(pairs); i >= 0 {
+		return fmt.Errorf("pair %d: %w", i, ErrSortedInputRequired)
+	}
+	t.freeLeaves()
+	t.root, t.length = nil, len(pairs)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	leafCounts := spread(len(pairs), t.order)
+	level := make([]*node[kT, vT], 0, len(leafCounts))
+	lowest := make([]kT, 0, len(leafCounts)) // smallest key of each subtree
+	for _, cnt := range leafCounts {
+		n := t.newLeaf()
+		for _, pair := range pairs[:cnt] {
+			n.keys = append(n.keys, pair.Key)
+			n.values = append(n.values, pair.Value)
+		}
+		n.bloomRebuild()
+		pairs = pairs[cnt:]
+		level = append(level, n)
+		lowest = append(lowest, n.keys[0])
+	}
+	link(level)
+
+	for len(level) > 1 {
+		childCounts := spread(len(level), t.order)
+		parents := make([]*node[kT, vT], 0, len(childCounts))
+		parentsLowest := make([]kT, 0, len(childCounts))
+		for _, cnt := range childCounts {
+			n := &node[kT, vT]{order: t.order, gen: t.gen}
+			n.children = make(items[*node[kT, vT]], cnt)
+			copy(n.children, level[:cnt])
+			n.keys = make(items[kT], cnt-1)
+			copy(n.keys, lowest[1:cnt])
+			for _, child := range n.children {
+				child.parent = n
+			}
+			parents = append(parents, n)
+			parentsLowest = append(parentsLowest, lowest[0])
+			level, lowest = level[cnt:], lowest[cnt:]
+		}
+		link(parents)
+		level, lowest = parents, parentsLowest
+	}
+	t.root = level[0]
+	return nil
+}
+
+// unsortedAt returns the index of the first pair whose key is not greater
+// than the key before it, or -1 if the pairs are strictly sorted.
+func (t *BPlusTree[kT, vT]) unsortedAt(pairs []Pair[kT, vT]) int {
+	for i := 1; i < len(pairs); i++ {
+		if !t.less(pairs[i-1].Key, pairs[i].Key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compact rebuilds the tree from its entries with densely filled leaves,
+// reclaiming the half-empty nodes left behind by heavy deletion.
+func (t *BPlusTree[kT, vT]) Compact() {
+	pairs := make([]Pair[kT, vT], 0, t.length)
+	t.Ascend(func(key kT, value vT) bool {
+		pairs = append(pairs, Pair[kT, vT]{Key: key, Value: value})
+		return true
+	})
+	_ = t.BulkLoad(pairs) // sorted by the walk of the leaf chain
+}
+
+// spread splits total items into the fewest groups holding at most max
+// items each, with the group sizes differing by at most one so that every
+// group stays above the minimum fill of a node.
+func spread(total, max int) []int {
+	groups := (total + max - 1) / max
+	out := make([]int, groups)
+	for i := range out {
+		out[i] = total / groups
+		if i < total%groups {
+			out[i]++
+		}
+	}
+	return out
+}
+
+// link chains the nodes of one level through their next and prev pointers.
+func link[kT, vT any](level []*node[kT, vT]) {
+	for i := 1; i < len(level); i++ {
+		level[i-1].next = level[i]
+		level[i].prev = level[i-1]
+	}
+}
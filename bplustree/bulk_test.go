@@ -0,0 +1,74 @@
+package bplustree
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBulkLoad(t *testing.T) {
+	for order := 3; order <= 8; order++ {
+		for n := 0; n <= 60; n++ {
+			pairs := make([]Pair[int, int], n)
+			for i := range pairs {
+				pairs[i] = Pair[int, int]{Key: i, Value: i * 10}
+			}
+			tree := New[int, int](order, intLess)
+			tree.BulkLoad(pairs)
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("order %d, %d pairs: %v", order, n, err)
+			}
+			if got := keysOf(tree); !reflect.DeepEqual(got, seq(0, n, 1)) {
+				t.Fatalf("order %d, %d pairs: unexpected keys %v", order, n, got)
+			}
+			// the tree keeps working after a bulk load
+			tree.Insert(-1, -10)
+			tree.Remove(n / 2)
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("order %d, %d pairs: %v after mutation", order, n, err)
+			}
+		}
+	}
+}
+
+func TestBulkLoadUnsorted(t *testing.T) {
+	tree := newIntTree(4, 1, 2, 3)
+	err := tree.BulkLoad([]Pair[int, int]{{Key: 1}, {Key: 3}, {Key: 2}})
+	if !errors.Is(err, ErrSortedInputRequired) {
+		t.Fatalf("expect ErrSortedInputRequired, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "pair 2") {
+		t.Fatalf("expect the offending index in %q", err)
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("expect the tree untouched, got %v", got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tree := newIntTree(4, seq(0, 2000, 1)...)
+	for key := 0; key < 2000; key++ {
+		if key%5 != 0 {
+			tree.Remove(key)
+		}
+	}
+	before := tree.Stats()
+	tree.Compact()
+	after := tree.Stats()
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if after.Len != before.Len || after.Len != 400 {
+		t.Fatalf("expect 400 entries before and after, got %d and %d", before.Len, after.Len)
+	}
+	if after.Leaves >= before.Leaves {
+		t.Fatalf("expect fewer leaves after compaction, got %d before and %d after", before.Leaves, after.Leaves)
+	}
+	if after.LeafFill < 0.99 {
+		t.Fatalf("expect full leaves after compaction, got fill %.2f", after.LeafFill)
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, seq(0, 2000, 5)) {
+		t.Fatalf("unexpected keys after compaction")
+	}
+}
@@ -0,0 +1,52 @@
+package bplustree
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WriteCSV writes the entries of the tree in ascending key order as CSV
+// records, enc turns each entry into the fields of a record. Records are
+// streamed to w as the leaf chain is walked.
+func (t *BPlusTree[kT, vT]) WriteCSV(w io.Writer, enc func(kT, vT) []string) error {
+	cw := csv.NewWriter(w)
+	var err error
+	t.Ascend(func(key kT, value vT) bool {
+		err = cw.Write(enc(key, value))
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV replaces the content of the tree with the entries decoded from the
+// CSV records of r by dec. The records must be sorted by key in strictly
+// ascending order, as written by WriteCSV, the tree is bulk loaded from them.
+func (t *BPlusTree[kT, vT]) ReadCSV(r io.Reader, dec func([]string) (kT, vT, error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	var pairs []Pair[kT, vT]
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		key, value, err := dec(record)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", len(pairs)+1, err)
+		}
+		pairs = append(pairs, Pair[kT, vT]{Key: key, Value: value})
+	}
+	if i := t.unsortedAt(pairs); i >= 0 {
+		return fmt.Errorf("record %d: %w", i+1, ErrSortedInputRequired)
+	}
+	return t.BulkLoad(pairs)
+}
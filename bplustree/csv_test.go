@@ -0,0 +1,60 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func encodeIntCSV(key, value int) []string {
+	return []string{strconv.Itoa(key), strconv.Itoa(value)}
+}
+
+func decodeIntCSV(record []string) (int, int, error) {
+	key, err := strconv.Atoi(record[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	value, err := strconv.Atoi(record[1])
+	return key, value, err
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	tree := newIntTree(4, seq(0, 300, 3)...)
+	buf := &bytes.Buffer{}
+	if err := tree.WriteCSV(buf, encodeIntCSV); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "0,0\n3,30\n6,60\n") {
+		t.Fatalf("unexpected csv output %q", buf.String()[:20])
+	}
+
+	loaded := New[int, int](5, intLess)
+	loaded.Insert(1000, 1) // replaced by the load
+	if err := loaded.ReadCSV(buf, decodeIntCSV); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	added, removed, changed := tree.Diff(loaded, func(a, b int) bool { return a == b })
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("expect identical trees, got %v %v %v", added, removed, changed)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tree := newIntTree(4, 1, 2, 3)
+	if err := tree.ReadCSV(strings.NewReader("1,10\n3,30\n2,20\n"), decodeIntCSV); !errors.Is(err, ErrSortedInputRequired) {
+		t.Fatalf("expect ErrSortedInputRequired on unsorted records, got %v", err)
+	}
+	if err := tree.ReadCSV(strings.NewReader("1,10\nx,20\n"), decodeIntCSV); err == nil {
+		t.Fatalf("expect an error on undecodable records")
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("expect the tree untouched after a failed read, got %v", got)
+	}
+}
@@ -0,0 +1,344 @@
+package bplustree
+
+import "sync/atomic"
+
+// Cursor points at an entry of the tree and moves along the leaf chain
+// from there. A cursor is invalidated by any mutation of the tree, except
+// for a snapshot cursor, see SnapshotCursor.
+type Cursor[kT, vT any] struct {
+	leaf  *node[kT, vT]
+	index int
+	tree  *BPlusTree[kT, vT] // nil for a snapshot cursor
+
+	// bounded only, the cursor stays within [lo, hi)
+	less    LessFunc[kT]
+	bounded bool
+	lo, hi  kT
+
+	// snapshot only, the cursor moves along the path from the root of the
+	// snapshot rather than the leaf chain
+	path     []pathStep[kT, vT]
+	snapshot *atomic.Int32 // the count of open snapshots of the tree
+}
+
+// Valid reports whether the cursor points at an entry.
+func (c *Cursor[kT, vT]) Valid() bool {
+	return c.leaf != nil
+}
+
+// Key returns the key of the current entry, the cursor must be valid.
+func (c *Cursor[kT, vT]) Key() kT {
+	return c.leaf.keys[c.index]
+}
+
+// Value returns the value of the current entry, the cursor must be valid.
+func (c *Cursor[kT, vT]) Value() vT {
+	return c.leaf.values[c.index]
+}
+
+// SetValue replaces the value of the current entry in place, the cursor
+// must be valid. The key, hence the ordering and the size of the tree, are
+// left untouched, so the cursor stays valid. It panics on a snapshot cursor.
+func (c *Cursor[kT, vT]) SetValue(value vT) {
+	if c.tree == nil {
+		panic("bplustree: SetValue on a snapshot cursor")
+	}
+	if c.tree.frozen(c.leaf) {
+		c.leaf = c.tree.own(c.Key(), false)
+	}
+	c.leaf.values[c.index] = value
+}
+
+// Next moves the cursor to the following entry and reports whether
+// the cursor is still valid.
+func (c *Cursor[kT, vT]) Next() bool {
+	if c.leaf == nil {
+		return false
+	}
+	c.index++
+	if c.index == len(c.leaf.keys) {
+		if c.snapshot != nil {
+			c.leaf, c.index = c.stepLeaf(1), 0
+		} else {
+			c.leaf, c.index = c.leaf.next, 0
+		}
+	}
+	c.checkBounds()
+	return c.Valid()
+}
+
+// Prev moves the cursor to the preceding entry and reports whether
+// the cursor is still valid.
+func (c *Cursor[kT, vT]) Prev() bool {
+	if c.leaf == nil {
+		return false
+	}
+	c.index--
+	if c.index < 0 {
+		if c.snapshot != nil {
+			c.leaf = c.stepLeaf(-1)
+		} else {
+			c.leaf = c.leaf.prev
+		}
+		if c.leaf != nil {
+			c.index = len(c.leaf.keys) - 1
+		}
+	}
+	c.checkBounds()
+	return c.Valid()
+}
+
+// checkBounds invalidates a bounded cursor once it leaves its window.
+func (c *Cursor[kT, vT]) checkBounds() {
+	if !c.bounded || c.leaf == nil {
+		return
+	}
+	key := c.Key()
+	if c.less(key, c.lo) || !c.less(key, c.hi) {
+		c.leaf = nil
+	}
+}
+
+// seek returns the leaf and the index of the first entry whose key is
+// greater than or equal to the given key, the leaf is nil if there is none.
+func (t *BPlusTree[kT, vT]) seek(key kT) (*node[kT, vT], int) {
+	if t.root == nil {
+		return nil, 0
+	}
+	n := t.root.findLeaf(key, t.less)
+	index, _ := n.keys.find(key, t.less)
+	if index == len(n.keys) {
+		// all keys in this leaf are smaller, the first entry of the
+		// next leaf is greater than the separator, hence the key.
+		return n.next, 0
+	}
+	return n, index
+}
+
+// GetRange returns a cursor at the first entry of the window [lo, hi). The
+// cursor is invalidated as soon as Next or Prev moves it out of the window,
+// so callers don't have to check the bounds on each step.
+func (t *BPlusTree[kT, vT]) GetRange(lo, hi kT) *Cursor[kT, vT] {
+	leaf, index := t.seek(lo)
+	c := &Cursor[kT, vT]{leaf: leaf, index: index, tree: t, less: t.less, bounded: true, lo: lo, hi: hi}
+	c.checkBounds()
+	return c
+}
+
+// LowerBound returns a cursor at the first entry whose key is greater than
+// or equal to the given key, the cursor is invalid if there is none.
+func (t *BPlusTree[kT, vT]) LowerBound(key kT) *Cursor[kT, vT] {
+	leaf, index := t.seek(key)
+	return &Cursor[kT, vT]{leaf: leaf, index: index, tree: t}
+}
+
+// UpperBound returns a cursor at the first entry whose key is greater than
+// the given key, the cursor is invalid if there is none.
+func (t *BPlusTree[kT, vT]) UpperBound(key kT) *Cursor[kT, vT] {
+	c := t.LowerBound(key)
+	if c.Valid() && !t.less(key, c.Key()) {
+		c.Next()
+	}
+	return c
+}
+
+// GetWithCursor returns the value stored under the given key along with a
+// cursor at its entry, so the caller can scan around it without descending
+// the tree again. If the key is absent, it returns false and a cursor at
+// the first entry whose key is greater, the insertion point of the key,
+// which is invalid if there is none.
+func (t *BPlusTree[kT, vT]) GetWithCursor(key kT) (_ vT, c *Cursor[kT, vT], _ bool) {
+	c, found := t.locate(key)
+	if !found {
+		return
+	}
+	return c.Value(), c, true
+}
+
+// locate returns a cursor at the entry of the given key and true, or at the
+// insertion point of the key and false if it is absent. Together with
+// Cursor.SetValue, it updates a value with a single descent.
+func (t *BPlusTree[kT, vT]) locate(key kT) (*Cursor[kT, vT], bool) {
+	c := t.LowerBound(key)
+	return c, c.Valid() && !t.less(key, c.Key())
+}
+
+// CountKey returns how many entries are stored under the given key, counted
+// along the leaf chain from LowerBound to UpperBound. Keys are unique in the
+// tree, so this is 0 or 1.
+func (t *BPlusTree[kT, vT]) CountKey(key kT) int {
+	cnt := 0
+	for c := t.LowerBound(key); c.Valid() && !t.less(key, c.Key()); c.Next() {
+		cnt++
+	}
+	return cnt
+}
+
+// Page returns up to size entries whose keys are strictly greater than
+// after, in ascending order, along with the key to pass as after to get the
+// next page, and whether there are entries beyond this page. The next key is
+// the last one returned, or after itself if the page is empty. FirstPage
+// returns the first page.
+func (t *BPlusTree[kT, vT]) Page(after kT, size int) (items []Pair[kT, vT], nextAfter kT, hasMore bool) {
+	return t.page(t.UpperBound(after), after, size)
+}
+
+// FirstPage returns up to size entries from the smallest key on, see Page.
+func (t *BPlusTree[kT, vT]) FirstPage(size int) (items []Pair[kT, vT], nextAfter kT, hasMore bool) {
+	var zero kT
+	return t.page(t.begin(), zero, size)
+}
+
+func (t *BPlusTree[kT, vT]) page(c *Cursor[kT, vT], after kT, size int) ([]Pair[kT, vT], kT, bool) {
+	var items []Pair[kT, vT]
+	for ; c.Valid() && len(items) < size; c.Next() {
+		items = append(items, Pair[kT, vT]{Key: c.Key(), Value: c.Value()})
+	}
+	if len(items) > 0 {
+		after = items[len(items)-1].Key
+	}
+	return items, after, c.Valid()
+}
+
+// RangeNotEmpty reports whether any key is in [lo, hi) with a single seek to
+// the first key greater than or equal to lo and one comparison against hi,
+// without iterating the window.
+func (t *BPlusTree[kT, vT]) RangeNotEmpty(lo, hi kT) bool {
+	leaf, index := t.seek(lo)
+	return leaf != nil && t.less(leaf.keys[index], hi)
+}
+
+// NearestN returns the n entries whose keys are the closest to the given
+// key according to dist, which must grow with the distance between keys in
+// the tree ordering, e.g. |a-b| for numbers. The entries are picked by
+// walking the leaf chain outward in both directions from where the key
+// belongs, and returned by increasing distance, a tie going to the smaller
+// key. Fewer than n entries are returned if the tree holds fewer.
+func (t *BPlusTree[kT, vT]) NearestN(key kT, n int, dist func(a, b kT) int) []Pair[kT, vT] {
+	if t.root == nil || n <= 0 {
+		return nil
+	}
+	right := t.LowerBound(key)
+	left := t.before(right)
+
+	var out []Pair[kT, vT]
+	for len(out) < n && (left.Valid() || right.Valid()) {
+		c := right
+		if !right.Valid() || left.Valid() && dist(key, left.Key()) <= dist(key, right.Key()) {
+			c = left
+		}
+		out = append(out, Pair[kT, vT]{Key: c.Key(), Value: c.Value()})
+		if c == left {
+			left.Prev()
+		} else {
+			right.Next()
+		}
+	}
+	return out
+}
+
+// DescendRange calls the iterator for the entries whose keys are in
+// (gt, le] in descending key order, walking the leaf chain backward from
+// the last entry whose key is less than or equal to le, until the iterator
+// returns false.
+func (t *BPlusTree[kT, vT]) DescendRange(le, gt kT, iter func(key kT, value vT) bool) {
+	for c := t.before(t.UpperBound(le)); c.Valid() && t.less(gt, c.Key()); c.Prev() {
+		if !iter(c.Key(), c.Value()) {
+			return
+		}
+	}
+}
+
+// Gaps returns the ranges of keys missing from the tree in [lo, hi), where
+// next returns the key expected right after the given one, e.g. k+1 for
+// sequence numbers. Each gap is returned as its first missing key and the
+// key ending it, exclusively: the next key present in the tree, or hi.
+func (t *BPlusTree[kT, vT]) Gaps(next func(kT) kT, lo, hi kT) [][2]kT {
+	var gaps [][2]kT
+	expected := lo
+	for c := t.GetRange(lo, hi); c.Valid(); c.Next() {
+		if t.less(expected, c.Key()) {
+			gaps = append(gaps, [2]kT{expected, c.Key()})
+		}
+		expected = next(c.Key())
+	}
+	if t.less(expected, hi) {
+		gaps = append(gaps, [2]kT{expected, hi})
+	}
+	return gaps
+}
+
+// before returns a new cursor at the entry preceding the one of the given
+// cursor, or at the last entry of the tree if the given cursor is invalid.
+func (t *BPlusTree[kT, vT]) before(c *Cursor[kT, vT]) *Cursor[kT, vT] {
+	if t.root == nil {
+		return &Cursor[kT, vT]{}
+	}
+	if !c.Valid() {
+		last := t.root.rightmostLeaf()
+		return &Cursor[kT, vT]{leaf: last, index: len(last.keys) - 1, tree: t}
+	}
+	prev := &Cursor[kT, vT]{leaf: c.leaf, index: c.index, tree: t}
+	prev.Prev()
+	return prev
+}
+
+// begin returns a cursor at the first entry of the tree.
+func (t *BPlusTree[kT, vT]) begin() *Cursor[kT, vT] {
+	if t.root == nil {
+		return &Cursor[kT, vT]{}
+	}
+	return &Cursor[kT, vT]{leaf: t.root.leftmostLeaf(), tree: t}
+}
+
+// LeafCursor points at a whole leaf of the tree and moves along the leaf
+// chain one leaf at a time, for consumers batching a leaf's worth of
+// entries. Like Cursor, it is invalidated by any mutation of the tree.
+type LeafCursor[kT, vT any] struct {
+	leaf *node[kT, vT]
+}
+
+// FirstLeaf returns a leaf cursor at the leftmost leaf, the cursor is
+// invalid if the tree is empty.
+func (t *BPlusTree[kT, vT]) FirstLeaf() *LeafCursor[kT, vT] {
+	if t.root == nil {
+		return &LeafCursor[kT, vT]{}
+	}
+	return &LeafCursor[kT, vT]{leaf: t.root.leftmostLeaf()}
+}
+
+// Valid reports whether the cursor points at a leaf.
+func (c *LeafCursor[kT, vT]) Valid() bool {
+	return c.leaf != nil
+}
+
+// Entries returns a copy of the entries of the current leaf in ascending
+// order, or nil if the cursor is invalid.
+func (c *LeafCursor[kT, vT]) Entries() []Pair[kT, vT] {
+	if c.leaf == nil {
+		return nil
+	}
+	entries := make([]Pair[kT, vT], len(c.leaf.keys))
+	for i, key := range c.leaf.keys {
+		entries[i] = Pair[kT, vT]{Key: key, Value: c.leaf.values[i]}
+	}
+	return entries
+}
+
+// Next moves the cursor to the following leaf and reports whether the
+// cursor is still valid.
+func (c *LeafCursor[kT, vT]) Next() bool {
+	if c.leaf != nil {
+		c.leaf = c.leaf.next
+	}
+	return c.Valid()
+}
+
+// Prev moves the cursor to the preceding leaf and reports whether the
+// cursor is still valid.
+func (c *LeafCursor[kT, vT]) Prev() bool {
+	if c.leaf != nil {
+		c.leaf = c.leaf.prev
+	}
+	return c.Valid()
+}
@@ -0,0 +1,413 @@
+package bplustree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func cursorKeys(c *Cursor[int, int]) []int {
+	var out []int
+	for ; c.Valid(); c.Next() {
+		out = append(out, c.Key())
+	}
+	return out
+}
+
+func TestGetRange(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 2)...)
+	cases := []struct {
+		name   string
+		lo, hi int
+		expect []int
+	}{
+		{name: "empty window", lo: 10, hi: 10, expect: nil},
+		{name: "no key in window", lo: 51, hi: 52, expect: nil},
+		{name: "past the last key", lo: 200, hi: 300, expect: nil},
+		{name: "one leaf", lo: 3, hi: 7, expect: []int{4, 6}},
+		{name: "many leaves", lo: 10, hi: 41, expect: seq(10, 41, 2)},
+		{name: "whole tree", lo: -5, hi: 500, expect: seq(0, 100, 2)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := cursorKeys(tree.GetRange(c.lo, c.hi))
+			if !reflect.DeepEqual(got, c.expect) {
+				t.Fatalf("expect %v, got %v", c.expect, got)
+			}
+		})
+	}
+
+	c := tree.GetRange(10, 20)
+	if !c.Valid() || c.Value() != 100 {
+		t.Fatalf("expect cursor at 10 with value 100")
+	}
+	if c.Prev() {
+		t.Fatalf("expect cursor to stop below the lower bound, got %d", c.Key())
+	}
+}
+
+func TestLowerUpperBound(t *testing.T) {
+	tree := newIntTree(3, seq(0, 50, 5)...)
+	cases := []struct {
+		key          int
+		lower, upper int // -1 means an invalid cursor
+	}{
+		{key: 20, lower: 20, upper: 25}, // present
+		{key: 22, lower: 25, upper: 25}, // absent
+		{key: -1, lower: 0, upper: 0},
+		{key: 0, lower: 0, upper: 5},
+		{key: 45, lower: 45, upper: -1},
+		{key: 46, lower: -1, upper: -1},
+	}
+	at := func(c *Cursor[int, int]) int {
+		if !c.Valid() {
+			return -1
+		}
+		return c.Key()
+	}
+	for _, c := range cases {
+		if got := at(tree.LowerBound(c.key)); got != c.lower {
+			t.Fatalf("LowerBound(%d) expect %d, got %d", c.key, c.lower, got)
+		}
+		if got := at(tree.UpperBound(c.key)); got != c.upper {
+			t.Fatalf("UpperBound(%d) expect %d, got %d", c.key, c.upper, got)
+		}
+	}
+
+	empty := New[int, int](3, intLess)
+	if empty.LowerBound(1).Valid() || empty.UpperBound(1).Valid() {
+		t.Fatalf("expect invalid cursors on an empty tree")
+	}
+}
+
+func TestGetWithCursor(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 2)...)
+	value, c, ok := tree.GetWithCursor(40)
+	if !ok || value != 400 || !c.Valid() || c.Key() != 40 {
+		t.Fatalf("expect a hit on 40, got %d-%v", value, ok)
+	}
+	if !c.Next() || c.Key() != 42 {
+		t.Fatal("expect Next to move to the successor")
+	}
+	if !c.Prev() || !c.Prev() || c.Key() != 38 {
+		t.Fatal("expect Prev to move to the predecessor")
+	}
+
+	value, c, ok = tree.GetWithCursor(41)
+	if ok || value != 0 || !c.Valid() || c.Key() != 42 {
+		t.Fatalf("expect a miss on 41 with a cursor at 42, got %d-%v", value, ok)
+	}
+	if _, c, ok = tree.GetWithCursor(500); ok || c.Valid() {
+		t.Fatal("expect a miss past the last key with an invalid cursor")
+	}
+	if _, c, ok = New[int, int](4, intLess).GetWithCursor(1); ok || c.Valid() {
+		t.Fatal("expect a miss with an invalid cursor on an empty tree")
+	}
+}
+
+func TestPage(t *testing.T) {
+	empty := New[int, int](4, intLess)
+	if items, next, more := empty.FirstPage(10); len(items) != 0 || next != 0 || more {
+		t.Fatalf("expect an empty first page, got %v %d %v", items, next, more)
+	}
+	if items, next, more := empty.Page(5, 10); len(items) != 0 || next != 5 || more {
+		t.Fatalf("expect an empty page, got %v %d %v", items, next, more)
+	}
+
+	for _, c := range []struct {
+		entries, size int
+		pages         []int
+	}{
+		{entries: 30, size: 10, pages: []int{10, 10, 10}}, // exact-size pages
+		{entries: 25, size: 10, pages: []int{10, 10, 5}},  // a final short page
+		{entries: 3, size: 10, pages: []int{3}},
+	} {
+		tree := newIntTree(4, seq(0, c.entries*2, 2)...)
+		items, next, more := tree.FirstPage(c.size)
+		var got []int
+		for i, size := range c.pages {
+			if len(items) != size || more != (i < len(c.pages)-1) {
+				t.Fatalf("%d entries: page %d expect %d entries and more %v, got %d %v", c.entries, i, size, i < len(c.pages)-1, len(items), more)
+			}
+			for _, item := range items {
+				if item.Value != item.Key*10 {
+					t.Fatalf("key %d: expect value %d, got %d", item.Key, item.Key*10, item.Value)
+				}
+				got = append(got, item.Key)
+			}
+			if next != items[len(items)-1].Key {
+				t.Fatalf("expect the next key to be the last returned, got %d", next)
+			}
+			items, next, more = tree.Page(next, c.size)
+		}
+		if len(items) != 0 || more {
+			t.Fatalf("%d entries: expect nothing after the last page, got %v", c.entries, items)
+		}
+		if !reflect.DeepEqual(got, keysOf(tree)) {
+			t.Fatalf("%d entries: expect every key once, got %v", c.entries, got)
+		}
+	}
+
+	// after a key that is absent starts at the next one
+	tree := newIntTree(4, seq(0, 20, 2)...)
+	if items, _, more := tree.Page(5, 2); len(items) != 2 || items[0].Key != 6 || items[1].Key != 8 || !more {
+		t.Fatalf("expect 6 and 8 after 5, got %v", items)
+	}
+}
+
+func TestRangeNotEmpty(t *testing.T) {
+	if New[int, int](4, intLess).RangeNotEmpty(0, 100) {
+		t.Fatal("expect every window of an empty tree to be empty")
+	}
+	tree := newIntTree(4, seq(0, 100, 10)...)
+	for _, c := range []struct {
+		lo, hi int
+		expect bool
+	}{
+		{lo: 1, hi: 10, expect: false},   // between two entries
+		{lo: 10, hi: 10, expect: false},  // empty window on an entry
+		{lo: 10, hi: 11, expect: true},   // starts exactly on an entry
+		{lo: 11, hi: 20, expect: false},  // hi is exclusive
+		{lo: 11, hi: 21, expect: true},   // a single entry at the boundary
+		{lo: 91, hi: 200, expect: false}, // past the last entry
+		{lo: -50, hi: 1, expect: true},
+		{lo: 50, hi: 40, expect: false},
+	} {
+		if got := tree.RangeNotEmpty(c.lo, c.hi); got != c.expect {
+			t.Fatalf("[%d, %d): expect %v, got %v", c.lo, c.hi, c.expect, got)
+		}
+	}
+}
+
+func TestDescendRange(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 2)...)
+	cases := []struct {
+		name   string
+		le, gt int
+	}{
+		{name: "empty window", le: 10, gt: 10},
+		{name: "no key in window", le: 51, gt: 50},
+		{name: "below the first key", le: -1, gt: -10},
+		{name: "one leaf", le: 7, gt: 3},
+		{name: "many leaves", le: 41, gt: 9},
+		{name: "bounds on keys", le: 40, gt: 10},
+		{name: "whole tree", le: 500, gt: -5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []int
+			tree.DescendRange(c.le, c.gt, func(key, value int) bool {
+				if value != key*10 {
+					t.Fatalf("expect value %d for key %d, got %d", key*10, key, value)
+				}
+				got = append(got, key)
+				return true
+			})
+			// (gt, le] is [gt+1, le+1) for ints.
+			ascending := cursorKeys(tree.GetRange(c.gt+1, c.le+1))
+			if len(got) != len(ascending) {
+				t.Fatalf("expect the reverse of %v, got %v", ascending, got)
+			}
+			for i, key := range got {
+				if key != ascending[len(ascending)-1-i] {
+					t.Fatalf("expect the reverse of %v, got %v", ascending, got)
+				}
+			}
+		})
+	}
+
+	var got []int
+	tree.DescendRange(100, 0, func(key, _ int) bool {
+		got = append(got, key)
+		return len(got) < 3
+	})
+	if !reflect.DeepEqual(got, []int{98, 96, 94}) {
+		t.Fatalf("expect an early stop after 3 entries, got %v", got)
+	}
+	New[int, int](4, intLess).DescendRange(10, 0, func(int, int) bool {
+		t.Fatal("expect no entry in an empty tree")
+		return false
+	})
+}
+
+func TestGaps(t *testing.T) {
+	next := func(k int) int { return k + 1 }
+	tree := New[int, int](4, intLess)
+	for _, key := range seq(0, 100, 1) {
+		if key == 10 || key >= 20 && key < 25 || key == 60 || key == 61 || key >= 95 {
+			continue
+		}
+		tree.Insert(key, key)
+	}
+	cases := []struct {
+		name   string
+		lo, hi int
+		expect [][2]int
+	}{
+		{name: "whole range", lo: 0, hi: 100, expect: [][2]int{{10, 11}, {20, 25}, {60, 62}, {95, 100}}},
+		{name: "no gap", lo: 30, hi: 60, expect: nil},
+		{name: "window starting in a gap", lo: 22, hi: 40, expect: [][2]int{{22, 25}}},
+		{name: "window ending in a gap", lo: 50, hi: 61, expect: [][2]int{{60, 61}}},
+		{name: "before the first key", lo: -5, hi: 2, expect: [][2]int{{-5, 0}}},
+		{name: "empty window", lo: 10, hi: 10, expect: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := tree.Gaps(next, c.lo, c.hi); !reflect.DeepEqual(got, c.expect) {
+				t.Fatalf("expect %v, got %v", c.expect, got)
+			}
+		})
+	}
+	if got := New[int, int](4, intLess).Gaps(next, 0, 10); !reflect.DeepEqual(got, [][2]int{{0, 10}}) {
+		t.Fatalf("expect the whole window missing from an empty tree, got %v", got)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 2)...)
+	for key := 0; key < 100; key += 6 {
+		c, found := tree.locate(key)
+		if !found {
+			t.Fatalf("expect key %d located", key)
+		}
+		c.SetValue(-key)
+		if !c.Valid() || c.Key() != key || c.Value() != -key {
+			t.Fatalf("expect the cursor to stay at key %d", key)
+		}
+	}
+	for key := 0; key < 100; key += 2 {
+		expect := key * 10
+		if key%6 == 0 {
+			expect = -key
+		}
+		if got, _ := tree.Get(key); got != expect {
+			t.Fatalf("key %d: expect %d, got %d", key, expect, got)
+		}
+	}
+	if tree.Len() != 50 {
+		t.Fatalf("expect the size untouched, got %d", tree.Len())
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c, found := tree.locate(7); found || !c.Valid() || c.Key() != 8 {
+		t.Fatal("expect an absent key located at its insertion point")
+	}
+}
+
+func TestCountKey(t *testing.T) {
+	if cnt := New[int, int](4, intLess).CountKey(1); cnt != 0 {
+		t.Fatalf("expect no entry in an empty tree, got %d", cnt)
+	}
+	tree := newIntTree(4, seq(0, 100, 2)...)
+	for key := -1; key <= 101; key++ {
+		expect := 0
+		if key >= 0 && key < 100 && key%2 == 0 {
+			expect = 1
+		}
+		if cnt := tree.CountKey(key); cnt != expect {
+			t.Fatalf("key %d: expect %d entries, got %d", key, expect, cnt)
+		}
+	}
+}
+
+func TestNearestN(t *testing.T) {
+	dist := func(a, b int) int {
+		if a < b {
+			return b - a
+		}
+		return a - b
+	}
+	pairKeys := func(pairs []Pair[int, int]) []int {
+		var out []int
+		for _, pair := range pairs {
+			if pair.Value != pair.Key*10 {
+				t.Fatalf("expect value %d for key %d, got %d", pair.Key*10, pair.Key, pair.Value)
+			}
+			out = append(out, pair.Key)
+		}
+		return out
+	}
+
+	tree := newIntTree(4, seq(0, 100, 10)...)
+	cases := []struct {
+		name   string
+		key, n int
+		expect []int
+	}{
+		{name: "exact key", key: 40, n: 3, expect: []int{40, 30, 50}},
+		{name: "between keys", key: 43, n: 4, expect: []int{40, 50, 30, 60}},
+		{name: "tie goes to the smaller key", key: 45, n: 2, expect: []int{40, 50}},
+		{name: "below the range", key: -7, n: 3, expect: []int{0, 10, 20}},
+		{name: "above the range", key: 500, n: 3, expect: []int{90, 80, 70}},
+		{name: "more than the tree holds", key: 50, n: 20, expect: []int{50, 40, 60, 30, 70, 20, 80, 10, 90, 0}},
+		{name: "none", key: 50, n: 0, expect: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := pairKeys(tree.NearestN(c.key, c.n, dist)); !reflect.DeepEqual(got, c.expect) {
+				t.Fatalf("expect %v, got %v", c.expect, got)
+			}
+		})
+	}
+	if got := New[int, int](4, intLess).NearestN(1, 3, dist); got != nil {
+		t.Fatalf("expect nothing from an empty tree, got %v", got)
+	}
+}
+
+func TestLeafCursor(t *testing.T) {
+	if c := New[int, int](4, intLess).FirstLeaf(); c.Valid() || c.Entries() != nil || c.Next() {
+		t.Fatal("expect an invalid leaf cursor on an empty tree")
+	}
+
+	tree := newIntTree(4, seq(0, 50, 1)...)
+	var keys []int
+	leaves := 0
+	c := tree.FirstLeaf()
+	for {
+		entries := c.Entries()
+		if len(entries) == 0 {
+			t.Fatal("expect a leaf holding entries")
+		}
+		for _, e := range entries {
+			if e.Value != e.Key*10 {
+				t.Fatalf("expect value %d for key %d, got %d", e.Key*10, e.Key, e.Value)
+			}
+			keys = append(keys, e.Key)
+		}
+		leaves++
+		if c.leaf.next == nil {
+			break
+		}
+		c.Next()
+	}
+	if !reflect.DeepEqual(keys, seq(0, 50, 1)) {
+		t.Fatalf("expect the leaves to cover all keys in order, got %v", keys)
+	}
+	if expect := tree.Stats().Leaves; leaves != expect {
+		t.Fatalf("expect %d leaves, got %d", expect, leaves)
+	}
+
+	// walk back from the last leaf.
+	keys = nil
+	for ; c.Valid(); c.Prev() {
+		entries := c.Entries()
+		for i := len(entries) - 1; i >= 0; i-- {
+			keys = append(keys, entries[i].Key)
+		}
+	}
+	for i, key := range keys {
+		if key != 49-i {
+			t.Fatalf("expect the leaves to cover all keys backwards, got %v", keys)
+		}
+	}
+	if len(keys) != 50 {
+		t.Fatalf("expect the leaves to cover all keys backwards, got %v", keys)
+	}
+
+	// entries are copies.
+	entries := tree.FirstLeaf().Entries()
+	entries[0].Value = -1
+	if v, _ := tree.Get(entries[0].Key); v != 0 {
+		t.Fatalf("expect the tree unchanged by editing entries, got %d", v)
+	}
+}
@@ -0,0 +1,33 @@
+package bplustree
+
+// Diff compares this tree against the other one by walking both leaf chains
+// in lockstep, it returns the keys only present in other (added), the keys
+// only present in this tree (removed) and the keys present in both whose
+// values differ according to valueEq (changed), all in ascending order.
+// Both trees must use the same ordering.
+func (t *BPlusTree[kT, vT]) Diff(other *BPlusTree[kT, vT], valueEq func(a, b vT) bool) (added, removed, changed []kT) {
+	a, b := t.begin(), other.begin()
+	for a.Valid() && b.Valid() {
+		switch ka, kb := a.Key(), b.Key(); {
+		case t.less(ka, kb):
+			removed = append(removed, ka)
+			a.Next()
+		case t.less(kb, ka):
+			added = append(added, kb)
+			b.Next()
+		default:
+			if !valueEq(a.Value(), b.Value()) {
+				changed = append(changed, ka)
+			}
+			a.Next()
+			b.Next()
+		}
+	}
+	for ; a.Valid(); a.Next() {
+		removed = append(removed, a.Key())
+	}
+	for ; b.Valid(); b.Next() {
+		added = append(added, b.Key())
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package bplustree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	eq := func(a, b int) bool { return a == b }
+	cases := []struct {
+		name                    string
+		a, b                    *BPlusTree[int, int]
+		added, removed, changed []int
+	}{
+		{
+			name:    "disjoint",
+			a:       newIntTree(3, seq(0, 10, 2)...),
+			b:       newIntTree(3, seq(1, 10, 2)...),
+			added:   seq(1, 10, 2),
+			removed: seq(0, 10, 2),
+		},
+		{
+			name: "identical",
+			a:    newIntTree(3, seq(0, 50, 1)...),
+			b:    newIntTree(4, seq(0, 50, 1)...),
+		},
+		{
+			name:    "partially overlapping",
+			a:       newIntTree(3, seq(0, 30, 1)...),
+			b:       newIntTree(3, seq(20, 40, 1)...),
+			added:   seq(30, 40, 1),
+			removed: seq(0, 20, 1),
+		},
+		{
+			name:  "empty against populated",
+			a:     New[int, int](3, intLess),
+			b:     newIntTree(3, 1, 2),
+			added: []int{1, 2},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			added, removed, changed := c.a.Diff(c.b, eq)
+			if !reflect.DeepEqual(added, c.added) ||
+				!reflect.DeepEqual(removed, c.removed) ||
+				!reflect.DeepEqual(changed, c.changed) {
+				t.Fatalf("expect %v %v %v, got %v %v %v", c.added, c.removed, c.changed, added, removed, changed)
+			}
+		})
+	}
+
+	a, b := newIntTree(4, seq(0, 20, 1)...), newIntTree(4, seq(0, 20, 1)...)
+	b.Insert(5, 0)
+	b.Insert(17, 0)
+	if _, _, changed := a.Diff(b, eq); !reflect.DeepEqual(changed, []int{5, 17}) {
+		t.Fatalf("expect changed [5 17], got %v", changed)
+	}
+}
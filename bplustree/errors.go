@@ -0,0 +1,15 @@
+package bplustree
+
+import "errors"
+
+var (
+	// ErrInvalidOrder is returned by TryNew, and is the panic value of New,
+	// wrapped, for an order too small to keep the tree balanced.
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrSortedInputRequired is returned, wrapped, by the loaders requiring
+	// the keys of their input to be strictly ascending.
+	ErrSortedInputRequired = errors.New("keys are not strictly ascending")
+	// ErrIncompatibleTrees is returned, wrapped, by RestoreTopology for a
+	// dump taken from a tree of a different order.
+	ErrIncompatibleTrees = errors.New("incompatible trees")
+)
@@ -9,12 +9,11 @@ import (
 	"strings"
 
 	"github.com/maxnilz/tree/bplustree"
+	"github.com/maxnilz/tree/order"
 )
 
 func main() {
-	order := 4
-	less := func(a, b int) bool { return a < b }
-	tree := bplustree.New[int, int](order, less)
+	tree := bplustree.New[int, int](4, order.Less[int])
 	key, value := 0, 0
 	reader := bufio.NewReader(os.Stdin)
 	for {
@@ -0,0 +1,129 @@
+package bplustree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveFile writes the entries of the tree to the file at path, enc turns
+// each entry into its binary form. The entries are written to a temporary
+// file in the same directory which is then renamed over path, so a save
+// interrupted half-way leaves any previous file at path intact. The file
+// keeps the permissions of the file it replaces, a new one gets 0644.
+func (t *BPlusTree[kT, vT]) SaveFile(path string, enc func(kT, vT) ([]byte, error)) (err error) {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+	w := bufio.NewWriter(f)
+	if err = t.writeEntries(w, enc); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = f.Chmod(mode); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes the directory at path to disk, making a rename within it
+// durable.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// LoadFile replaces the content of the tree with the entries of the file at
+// path written by SaveFile, dec turns the binary form back into an entry.
+func (t *BPlusTree[kT, vT]) LoadFile(path string, dec func([]byte) (kT, vT, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pairs, err := readEntries(bufio.NewReader(f), dec)
+	if err != nil {
+		return err
+	}
+	return t.BulkLoad(pairs)
+}
+
+// writeEntries writes each entry in ascending key order as its length in
+// uvarint followed by its binary form.
+func (t *BPlusTree[kT, vT]) writeEntries(w io.Writer, enc func(kT, vT) ([]byte, error)) error {
+	var err error
+	var size [binary.MaxVarintLen64]byte
+	t.Ascend(func(key kT, value vT) bool {
+		var b []byte
+		if b, err = enc(key, value); err != nil {
+			return false
+		}
+		n := binary.PutUvarint(size[:], uint64(len(b)))
+		if _, err = w.Write(size[:n]); err != nil {
+			return false
+		}
+		_, err = w.Write(b)
+		return err == nil
+	})
+	return err
+}
+
+// readEntries reads the entries written by writeEntries until the end of r.
+func readEntries[kT, vT any](r *bufio.Reader, dec func([]byte) (kT, vT, error)) ([]Pair[kT, vT], error) {
+	var pairs []Pair[kT, vT]
+	for {
+		size, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRecordSize(size); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(pairs), err)
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(pairs), err)
+		}
+		key, value, err := dec(b)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(pairs), err)
+		}
+		pairs = append(pairs, Pair[kT, vT]{Key: key, Value: value})
+	}
+}
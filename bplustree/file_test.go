@@ -0,0 +1,124 @@
+package bplustree
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func encodeIntBinary(key, value int) ([]byte, error) {
+	b := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutVarint(b, int64(key))
+	n += binary.PutVarint(b[n:], int64(value))
+	return b[:n], nil
+}
+
+func decodeIntBinary(b []byte) (int, int, error) {
+	key, n := binary.Varint(b)
+	value, m := binary.Varint(b[n:])
+	if n <= 0 || m <= 0 {
+		return 0, 0, errors.New("malformed entry")
+	}
+	return int(key), int(value), nil
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	tree := newIntTree(4, seq(-100, 100, 7)...)
+	if err := tree.SaveFile(path, encodeIntBinary); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New[int, int](4, intLess)
+	if err := loaded.LoadFile(path, decodeIntBinary); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := keysOf(loaded); !reflect.DeepEqual(got, seq(-100, 100, 7)) {
+		t.Fatalf("unexpected keys %v", got)
+	}
+	if value, _ := loaded.Get(-51); value != -510 {
+		t.Fatalf("expect value -510, got %d", value)
+	}
+}
+
+func TestLoadFileOversizedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	if err := os.WriteFile(path, binary.AppendUvarint(nil, 1<<62), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tree := newIntTree(4, 1, 2, 3)
+	if err := tree.LoadFile(path, decodeIntBinary); err == nil {
+		t.Fatal("expect an error on an oversized entry")
+	}
+	if !reflect.DeepEqual(keysOf(tree), []int{1, 2, 3}) {
+		t.Fatal("expect a failed load to leave the tree untouched")
+	}
+}
+
+func TestSaveFileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	expectMode := func(mode os.FileMode) {
+		t.Helper()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != mode {
+			t.Fatalf("expect mode %v, got %v", mode, fi.Mode().Perm())
+		}
+	}
+	if err := newIntTree(4, 1, 2, 3).SaveFile(path, encodeIntBinary); err != nil {
+		t.Fatal(err)
+	}
+	expectMode(0o644)
+
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := newIntTree(4, 4, 5).SaveFile(path, encodeIntBinary); err != nil {
+		t.Fatal(err)
+	}
+	expectMode(0o640)
+}
+
+func TestSaveFileInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index")
+	if err := newIntTree(4, 1, 2, 3).SaveFile(path, encodeIntBinary); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCrash := errors.New("crash")
+	failing := func(key, value int) ([]byte, error) {
+		if key == 50 {
+			return nil, errCrash
+		}
+		return encodeIntBinary(key, value)
+	}
+	if err := newIntTree(4, seq(0, 100, 1)...).SaveFile(path, failing); !errors.Is(err, errCrash) {
+		t.Fatalf("expect the encoder error, got %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("expect the previous file intact")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expect the temporary file removed, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,66 @@
+package bplustree
+
+// PointerValues is a B+ tree keeping its values behind pointers, while still
+// taking and returning them by value. Splits, merges and in-node shifts then
+// move pointers instead of copying the values around, which pays off when
+// vT is a large struct, at the cost of one allocation per stored value and
+// an indirection on reads. For small values, use a plain BPlusTree.
+type PointerValues[kT, vT any] struct {
+	tree *BPlusTree[kT, *vT]
+}
+
+func NewPointerValues[kT, vT any](order int, less LessFunc[kT], opts ...Option[kT, *vT]) *PointerValues[kT, vT] {
+	return &PointerValues[kT, vT]{tree: New[kT, *vT](order, less, opts...)}
+}
+
+// Insert stores a copy of the value under the given key, it returns true if
+// the key existed already and its value got replaced. The value of an
+// existing key is overwritten in place, by the one the resolver returns if
+// the tree was created with WithOnConflict.
+func (p *PointerValues[kT, vT]) Insert(key kT, value vT) bool {
+	if ptr, ok := p.tree.Get(key); ok {
+		if p.tree.resolve != nil {
+			value = *p.tree.resolve(key, ptr, &value)
+		}
+		*ptr = value
+		return true
+	}
+	return p.tree.Insert(key, &value)
+}
+
+// Get returns a copy of the value stored under the given key.
+func (p *PointerValues[kT, vT]) Get(key kT) (_ vT, _ bool) {
+	ptr, ok := p.tree.Get(key)
+	if !ok {
+		return
+	}
+	return *ptr, true
+}
+
+// Remove removes the given key and returns its value.
+func (p *PointerValues[kT, vT]) Remove(key kT) (_ vT, _ bool) {
+	ptr, ok := p.tree.Remove(key)
+	if !ok {
+		return
+	}
+	return *ptr, true
+}
+
+// Len returns the number of key-value pairs.
+func (p *PointerValues[kT, vT]) Len() int {
+	return p.tree.Len()
+}
+
+// Ascend calls the iterator for every entry in ascending key order until
+// the iterator returns false.
+func (p *PointerValues[kT, vT]) Ascend(iter func(key kT, value vT) bool) {
+	p.tree.Ascend(func(key kT, ptr *vT) bool {
+		return iter(key, *ptr)
+	})
+}
+
+// Tree returns the underlying tree of pointers, for the operations not
+// wrapped here. Writing through the pointers updates the stored values.
+func (p *PointerValues[kT, vT]) Tree() *BPlusTree[kT, *vT] {
+	return p.tree
+}
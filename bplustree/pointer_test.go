@@ -0,0 +1,91 @@
+package bplustree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type payload struct {
+	id   int
+	data [504]byte
+}
+
+func TestPointerValues(t *testing.T) {
+	p := NewPointerValues[int, payload](4, intLess)
+	for key := 0; key < 100; key++ {
+		if p.Insert(key, payload{id: key}) {
+			t.Fatalf("expect key %d to be new", key)
+		}
+	}
+	value := payload{id: 1000}
+	if !p.Insert(5, value) {
+		t.Fatalf("expect key 5 to be replaced")
+	}
+	value.id = 2000 // the tree holds its own copy
+	if got, _ := p.Get(5); got.id != 1000 {
+		t.Fatalf("expect id 1000, got %d", got.id)
+	}
+	got, ok := p.Remove(6)
+	if !ok || got.id != 6 {
+		t.Fatalf("expect to remove id 6, got %d-%v", got.id, ok)
+	}
+	if _, ok := p.Get(6); ok || p.Len() != 99 {
+		t.Fatalf("expect key 6 removed and 99 entries left")
+	}
+	prev := -1
+	p.Ascend(func(key int, value payload) bool {
+		if key <= prev || (key != 5 && value.id != key) {
+			t.Fatalf("unexpected entry %d-%d", key, value.id)
+		}
+		prev = key
+		return true
+	})
+	if err := p.Tree().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPointerValuesOnConflict(t *testing.T) {
+	sum := func(_ int, existing, incoming *int) *int {
+		out := *existing + *incoming
+		return &out
+	}
+	p := NewPointerValues[int, int](4, intLess, WithOnConflict[int, *int](sum))
+	p.Insert(1, 10)
+	ptr, _ := p.Tree().Get(1)
+	if !p.Insert(1, 5) {
+		t.Fatal("expect key 1 to be replaced")
+	}
+	if got, _ := p.Get(1); got != 15 {
+		t.Fatalf("expect the resolver to sum to 15, got %d", got)
+	}
+	if *ptr != 15 {
+		t.Fatalf("expect the value updated in place, got %d", *ptr)
+	}
+}
+
+func BenchmarkLargeValues(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(10000)
+	b.Run("plain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := New[int, payload](32, intLess)
+			for _, key := range keys {
+				tree.Insert(key, payload{id: key})
+			}
+			for _, key := range keys {
+				tree.Remove(key)
+			}
+		}
+	})
+	b.Run("pointer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := NewPointerValues[int, payload](32, intLess)
+			for _, key := range keys {
+				tree.Insert(key, payload{id: key})
+			}
+			for _, key := range keys {
+				tree.Remove(key)
+			}
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package bplustree
+
+// SnapshotCursor returns a cursor at the first entry of a point-in-time
+// view of the tree, which later writes to the tree don't affect. Taking it
+// costs O(1): the snapshot shares the nodes of the tree, and from then on a
+// writer copies a shared node, along with its path from the root, the first
+// time it modifies it, leaving the nodes of the snapshot untouched. So the
+// snapshot can be scanned from another goroutine while writers keep mutating
+// the tree, only the call itself must not race with writers.
+//
+// An open snapshot retains the nodes the writers replaced since it was
+// taken, at most as much memory as the tree had then, and makes the writers
+// copy every node they modify for the first time, until Close is called on
+// the cursor of every open snapshot. The cursor walks the nodes of the
+// snapshot rather than the leaf chain, which the writers relink, Next and
+// Prev cost O(1) amortized. Values stored behind pointers, as by
+// PointerValues, are shared with the tree. SetValue panics on a snapshot
+// cursor.
+func (t *BPlusTree[kT, vT]) SnapshotCursor() *Cursor[kT, vT] {
+	if t.root == nil {
+		return &Cursor[kT, vT]{}
+	}
+	t.gen++ // every node of the tree is shared with the snapshot from now on
+	t.snapshots.Add(1)
+	c := &Cursor[kT, vT]{snapshot: &t.snapshots}
+	n := t.root
+	for !n.isLeaf {
+		c.path = append(c.path, pathStep[kT, vT]{node: n})
+		n = n.children[0]
+	}
+	c.leaf = n
+	return c
+}
+
+// pathStep is an internal node on the path of a snapshot cursor, along with
+// the index of the child the path goes down to.
+type pathStep[kT, vT any] struct {
+	node  *node[kT, vT]
+	index int
+}
+
+// stepLeaf moves a snapshot cursor to the leaf next to the current one in
+// the given direction, 1 or -1, along its path, it returns nil past either
+// end.
+func (c *Cursor[kT, vT]) stepLeaf(dir int) *node[kT, vT] {
+	for len(c.path) > 0 {
+		top := &c.path[len(c.path)-1]
+		top.index += dir
+		if top.index < 0 || top.index >= len(top.node.children) {
+			c.path = c.path[:len(c.path)-1]
+			continue
+		}
+		n := top.node.children[top.index]
+		for !n.isLeaf {
+			i := 0
+			if dir < 0 {
+				i = len(n.children) - 1
+			}
+			c.path = append(c.path, pathStep[kT, vT]{node: n, index: i})
+			n = n.children[i]
+		}
+		return n
+	}
+	return nil
+}
+
+// Close invalidates the cursor, and for a snapshot cursor releases the
+// snapshot: the cursor drops its references to the nodes of the snapshot,
+// and once every snapshot of the tree is closed, writers modify the nodes in
+// place again. Calling Close more than once has no further effect.
+func (c *Cursor[kT, vT]) Close() {
+	if c.snapshot != nil {
+		c.snapshot.Add(-1)
+		c.snapshot = nil
+	}
+	c.leaf, c.index, c.path = nil, 0, nil
+}
+
+// frozen reports whether the node may be shared with an open snapshot, so
+// it has to be copied before it gets modified.
+func (t *BPlusTree[kT, vT]) frozen(n *node[kT, vT]) bool {
+	return n.gen != t.gen && t.snapshots.Load() > 0
+}
+
+// own copies the nodes shared with an open snapshot on the path from the
+// root to the leaf the given key belongs to, and their siblings as well if
+// siblings is true, which a removal may steal from or merge with. It returns
+// the leaf, which the caller can then modify, along with the path nodes and
+// the siblings, without affecting any snapshot. Without open snapshots, it
+// is findLeaf.
+func (t *BPlusTree[kT, vT]) own(key kT, siblings bool) *node[kT, vT] {
+	if t.snapshots.Load() == 0 {
+		return t.root.findLeaf(key, t.less)
+	}
+	if t.frozen(t.root) {
+		t.root = t.clone(t.root)
+	}
+	n := t.root
+	for !n.isLeaf {
+		i := n.childIndex(key, t.less)
+		if siblings {
+			if i > 0 {
+				t.ownChild(n, i-1)
+			}
+			if i < len(n.children)-1 {
+				t.ownChild(n, i+1)
+			}
+		}
+		n = t.ownChild(n, i)
+	}
+	return n
+}
+
+// ownLeaf is own for the leaf n and its siblings, it returns n without a
+// descent if no snapshot is open.
+func (t *BPlusTree[kT, vT]) ownLeaf(n *node[kT, vT]) *node[kT, vT] {
+	if t.snapshots.Load() == 0 {
+		return n
+	}
+	return t.own(n.keys[0], true)
+}
+
+// ownChild replaces the i-th child of the given node by a copy if it is
+// frozen, and returns the child.
+func (t *BPlusTree[kT, vT]) ownChild(parent *node[kT, vT], i int) *node[kT, vT] {
+	if child := parent.children[i]; t.frozen(child) {
+		parent.children[i] = t.clone(child)
+	}
+	return parent.children[i]
+}
+
+// clone returns a copy of the node taking its place in the tree: the
+// children and the neighbors in the chain are linked to the copy, the
+// caller links it to the parent. Only the links of the original, which no
+// snapshot follows, are modified.
+func (t *BPlusTree[kT, vT]) clone(n *node[kT, vT]) *node[kT, vT] {
+	c := *n
+	c.gen = t.gen
+	c.keys, c.values, c.children = nil, nil, nil
+	if c.isLeaf {
+		c.alloc()
+	}
+	c.keys = append(c.keys, n.keys...)
+	c.values = append(c.values, n.values...)
+	c.children = append(c.children, n.children...)
+	if c.isLeaf {
+		c.bloomRebuild()
+	}
+	for _, child := range c.children {
+		child.parent = &c
+	}
+	if c.prev != nil {
+		c.prev.next = &c
+	}
+	if c.next != nil {
+		c.next.prev = &c
+	}
+	return &c
+}
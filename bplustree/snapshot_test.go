@@ -0,0 +1,113 @@
+package bplustree
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSnapshotCursor scans a snapshot while a writer mutates the tree from
+// another goroutine, run it with -race to check that the writers leave the
+// nodes of the snapshot alone.
+func TestSnapshotCursor(t *testing.T) {
+	tree := newIntTree(4, seq(0, 2000, 1)...)
+	c := tree.SnapshotCursor()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for key := 0; key < 2000; key += 2 {
+			tree.Remove(key)
+			tree.Insert(key+5000, key)
+		}
+		tree.RemoveAll(seq(1, 1000, 3))
+		tree.DeletePrefix(func(key int) bool { return key < 1200 }, 0)
+		tree.RemoveMin()
+		tree.RemoveMax()
+		for key := 1201; key < 2000; key += 10 {
+			tree.LowerBound(key).SetValue(-key)
+		}
+	}()
+	var got []int
+	for ; c.Valid(); c.Next() {
+		if c.Value() != c.Key()*10 {
+			t.Fatalf("unexpected value %d for key %d", c.Value(), c.Key())
+		}
+		got = append(got, c.Key())
+	}
+	<-done
+	c.Close()
+	if !reflect.DeepEqual(got, seq(0, 2000, 1)) {
+		t.Fatalf("expect the snapshot unaffected by the writes")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := tree.Get(1211); v != -1211 {
+		t.Fatalf("expect the value set through the cursor, got %d", v)
+	}
+
+	// writes in place again once the snapshot is closed
+	leaf := tree.root.findLeaf(1999, intLess)
+	tree.Insert(1999, 0)
+	if tree.root.findLeaf(1999, intLess) != leaf {
+		t.Fatal("expect the leaf modified in place after Close")
+	}
+	c.Close()
+	if c.Valid() || c.Next() {
+		t.Fatalf("expect a closed cursor to be invalid")
+	}
+	if New[int, int](4, intLess).SnapshotCursor().Valid() {
+		t.Fatalf("expect an invalid snapshot cursor on an empty tree")
+	}
+}
+
+func TestSnapshotCursorSharing(t *testing.T) {
+	tree := newIntTree(4, seq(0, 500, 1)...)
+	c := tree.SnapshotCursor()
+	defer c.Close()
+	var before []*node[int, int]
+	for n := tree.root.leftmostLeaf(); n != nil; n = n.next {
+		before = append(before, n)
+	}
+	tree.Insert(1000, 0)
+	shared := 0
+	for i, n := 0, tree.root.leftmostLeaf(); n != nil && i < len(before); i, n = i+1, n.next {
+		if n == before[i] {
+			shared++
+		}
+	}
+	if shared != len(before)-1 {
+		t.Fatalf("expect all leaves but the modified one shared, got %d of %d", shared, len(before))
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotCursorPrev(t *testing.T) {
+	tree := newIntTree(4, seq(0, 100, 1)...)
+	c := tree.SnapshotCursor()
+	defer c.Close()
+	for c.Valid() && c.Key() < 60 {
+		c.Next()
+	}
+	tree.RemoveAll(seq(0, 100, 2))
+	var got []int
+	for ; c.Valid(); c.Prev() {
+		got = append(got, c.Key())
+	}
+	var expect []int
+	for key := 60; key >= 0; key-- {
+		expect = append(expect, key)
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect the snapshot walked backwards, got %v", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect SetValue to panic on a snapshot cursor")
+		}
+	}()
+	s := tree.SnapshotCursor()
+	defer s.Close()
+	s.SetValue(1)
+}
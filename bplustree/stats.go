@@ -0,0 +1,83 @@
+package bplustree
+
+// Stats describes the shape of a tree.
+type Stats struct {
+	// Len is the number of key-value pairs.
+	Len int
+	// Height is the number of levels, a tree with only a leaf root has
+	// height 1 and an empty tree has height 0.
+	Height int
+	// Leaves and Internals are the number of leaf and internal nodes.
+	Leaves    int
+	Internals int
+	// LeafFill is the average ratio of keys held by a leaf to its capacity.
+	LeafFill float64
+}
+
+// Stats walks the whole tree and returns its shape.
+func (t *BPlusTree[kT, vT]) Stats() Stats {
+	stats := Stats{Len: t.length}
+	if t.root == nil {
+		return stats
+	}
+	for n := t.root; n != nil; n = n.children.front() {
+		stats.Height++
+	}
+	for n := t.root.leftmostLeaf(); n != nil; n = n.next {
+		stats.Leaves++
+		stats.LeafFill += float64(len(n.keys)) / float64(n.maxKeys())
+	}
+	stats.LeafFill /= float64(stats.Leaves)
+	q := items[*node[kT, vT]]{t.root}
+	for i := 0; i < len(q); i++ {
+		if n := q[i]; !n.isLeaf {
+			stats.Internals++
+			q = append(q, n.children...)
+		}
+	}
+	return stats
+}
+
+// FanoutHistogram walks the whole tree and returns how many internal nodes
+// have each number of children, and how many leaves hold each number of
+// entries.
+func (t *BPlusTree[kT, vT]) FanoutHistogram() (internals, leaves map[int]int) {
+	internals, leaves = map[int]int{}, map[int]int{}
+	if t.root == nil {
+		return
+	}
+	q := items[*node[kT, vT]]{t.root}
+	for i := 0; i < len(q); i++ {
+		n := q[i]
+		if n.isLeaf {
+			leaves[len(n.keys)]++
+			continue
+		}
+		internals[len(n.children)]++
+		q = append(q, n.children...)
+	}
+	return
+}
+
+// WalkNodes visits the nodes of the tree level by level, from left to right
+// within a level, passing the depth of each node (0 for the root), whether
+// it is a leaf and its keys, until visit returns false. The key slices are
+// copies, mutating them doesn't affect the tree.
+func (t *BPlusTree[kT, vT]) WalkNodes(visit func(depth int, isLeaf bool, keys []kT) bool) {
+	if t.root == nil {
+		return
+	}
+	level := []*node[kT, vT]{t.root}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []*node[kT, vT]
+		for _, n := range level {
+			keys := make([]kT, len(n.keys))
+			copy(keys, n.keys)
+			if !visit(depth, n.isLeaf, keys) {
+				return
+			}
+			next = append(next, n.children...)
+		}
+		level = next
+	}
+}
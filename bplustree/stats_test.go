@@ -0,0 +1,102 @@
+package bplustree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	if got := New[int, int](4, intLess).Stats(); got != (Stats{}) {
+		t.Fatalf("expect zero stats for an empty tree, got %+v", got)
+	}
+	tree := New[int, int](4, intLess)
+	pairs := make([]Pair[int, int], 16)
+	for i := range pairs {
+		pairs[i] = Pair[int, int]{Key: i}
+	}
+	tree.BulkLoad(pairs)
+	// 16 entries in 4 full leaves under a single root.
+	expect := Stats{Len: 16, Height: 2, Leaves: 4, Internals: 1, LeafFill: 1}
+	if got := tree.Stats(); got != expect {
+		t.Fatalf("expect %+v, got %+v", expect, got)
+	}
+}
+
+func TestFanoutHistogram(t *testing.T) {
+	internals, leaves := New[int, int](4, intLess).FanoutHistogram()
+	if len(internals) != 0 || len(leaves) != 0 {
+		t.Fatalf("expect empty histograms for an empty tree")
+	}
+
+	tree := newIntTree(4, seq(0, 500, 1)...)
+	for key := 0; key < 500; key += 3 {
+		tree.Remove(key)
+	}
+	internals, leaves = tree.FanoutHistogram()
+	stats := tree.Stats()
+	var nodes, entries, leafCount int
+	for size, cnt := range internals {
+		if size < 2 || size > tree.Order() {
+			t.Fatalf("unexpected fanout %d", size)
+		}
+		nodes += cnt
+	}
+	for size, cnt := range leaves {
+		entries += size * cnt
+		leafCount += cnt
+	}
+	if nodes != stats.Internals || leafCount != stats.Leaves || entries != stats.Len {
+		t.Fatalf("histograms disagree with stats %+v: %v %v", stats, internals, leaves)
+	}
+
+	tree = newIntTree(4, 1, 2, 3)
+	internals, leaves = tree.FanoutHistogram()
+	if len(internals) != 0 || !reflect.DeepEqual(leaves, map[int]int{3: 1}) {
+		t.Fatalf("expect a single leaf of 3 entries, got %v %v", internals, leaves)
+	}
+}
+
+func TestWalkNodes(t *testing.T) {
+	tree := New[int, int](3, intLess)
+	pairs := make([]Pair[int, int], 9)
+	for i := range pairs {
+		pairs[i] = Pair[int, int]{Key: i}
+	}
+	tree.BulkLoad(pairs)
+
+	type visited struct {
+		depth  int
+		isLeaf bool
+		keys   []int
+	}
+	var got []visited
+	tree.WalkNodes(func(depth int, isLeaf bool, keys []int) bool {
+		got = append(got, visited{depth, isLeaf, keys})
+		keys[0] = -1 // must not corrupt the tree
+		return true
+	})
+	expect := []visited{
+		{0, false, []int{3, 6}},
+		{1, true, []int{0, 1, 2}},
+		{1, true, []int{3, 4, 5}},
+		{1, true, []int{6, 7, 8}},
+	}
+	for i := range expect {
+		expect[i].keys[0] = -1
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("mutating visited keys corrupted the tree: %v", err)
+	}
+
+	cnt := 0
+	tree.WalkNodes(func(int, bool, []int) bool {
+		cnt++
+		return cnt < 2
+	})
+	if cnt != 2 {
+		t.Fatalf("expect the walk to stop after 2 nodes, got %d", cnt)
+	}
+}
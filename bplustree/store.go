@@ -0,0 +1,149 @@
+package bplustree
+
+// LeafStore provides the storage of the entries of the leaves, so that very
+// large indexes can carve it out of a few big allocations, e.g. a slab or
+// an arena, rather than having the Go heap track millions of small slices.
+//
+// The tree calls Alloc once for each leaf it creates and keeps the entries
+// of the leaf in the returned slices: inserting an entry shifts the
+// following ones one slot up, appending into the spare capacity, and
+// removing an entry shifts them one slot down and zeroes the vacated last
+// slot. A leaf never holds more than capacity entries, so the tree never
+// grows the slices beyond their capacity and they keep pointing into the
+// store. Once a leaf is dropped from the tree, by a merge or because the
+// tree was emptied or reloaded, the tree calls Free with its slices,
+// truncated to zero length, and doesn't touch them anymore.
+type LeafStore[kT, vT any] interface {
+	// Alloc returns empty key and value slices of at least the given
+	// capacity.
+	Alloc(capacity int) ([]kT, []vT)
+	// Free hands back the slices of a leaf dropped from the tree.
+	Free(keys []kT, values []vT)
+}
+
+// WithLeafStore makes the tree keep the entries of its leaves in store, see
+// LeafStore. By default they are kept in SliceStore.
+func WithLeafStore[kT, vT any](store LeafStore[kT, vT]) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		t.store = store
+	}
+}
+
+// SliceStore is the default LeafStore, which allocates the slices of each
+// leaf on the Go heap and leaves freeing them to the garbage collector.
+type SliceStore[kT, vT any] struct{}
+
+func (SliceStore[kT, vT]) Alloc(capacity int) ([]kT, []vT) {
+	return make([]kT, 0, capacity), make([]vT, 0, capacity)
+}
+
+func (SliceStore[kT, vT]) Free([]kT, []vT) {}
+
+// newLeaf returns an empty leaf of the tree whose entries are kept in the
+// leaf store of the tree.
+func (t *BPlusTree[kT, vT]) newLeaf() *node[kT, vT] {
+	n := &node[kT, vT]{order: t.order, isLeaf: true, slack: t.slack, store: t.store, appendSplit: t.appendSplit, gen: t.gen}
+	if t.bloom != nil {
+		n.bloom = t.bloom.build(nil)
+	}
+	n.alloc()
+	return n
+}
+
+// alloc sets the entries of this leaf to fresh slices of its store, which
+// hold the order+1 entries a leaf has at most, right before a split.
+func (n *node[kT, vT]) alloc() {
+	if n.store == nil {
+		return
+	}
+	keys, values := n.store.Alloc(n.order + 1)
+	n.keys, n.values = keys[:0], values[:0]
+}
+
+// free hands the entries of this leaf back to its store.
+func (n *node[kT, vT]) free() {
+	n.keys.truncate(0)
+	n.values.truncate(0)
+	if n.store != nil {
+		n.store.Free(n.keys, n.values)
+	}
+	n.keys, n.values = nil, nil
+}
+
+// freeLeaves hands the entries of every leaf of the tree back to the store
+// before the tree drops them, except for the leaves shared with an open
+// snapshot.
+func (t *BPlusTree[kT, vT]) freeLeaves() {
+	if t.root == nil {
+		return
+	}
+	for n := t.root.leftmostLeaf(); n != nil; {
+		next := n.next
+		if !t.frozen(n) {
+			n.free()
+		}
+		n = next
+	}
+}
+
+// NewWithHint is New for a tree expected to hold about expectedEntries
+// entries: the entries of the leaves are carved out of a single allocation
+// sized for as many leaves as that many entries need at worst, and the
+// slices of the leaves dropped by merges are reused. It is a hint, not a
+// limit, once the allocation is used up the leaves get fresh slices as with
+// New, and an overestimate costs the unused part of the allocation only.
+// Giving WithLeafStore overrides the hint.
+func NewWithHint[kT, vT any](order, expectedEntries int, less LessFunc[kT], opts ...Option[kT, vT]) *BPlusTree[kT, vT] {
+	t := New[kT, vT](order, less)
+	// every leaf but the root holds at least minKeys entries
+	leaf := &node[kT, vT]{order: order, isLeaf: true}
+	leaves := 0
+	if expectedEntries > 0 {
+		leaves = expectedEntries/leaf.minKeys() + 1
+	}
+	t.store = newSlabStore[kT, vT](leaves, order+1)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// slabStore is the LeafStore of NewWithHint, which carves the slices of the
+// leaves out of two slabs and keeps the freed slices for reuse.
+type slabStore[kT, vT any] struct {
+	keys   []kT
+	values []vT
+	free   []slabSlot[kT, vT]
+	spills int // number of slices allocated once the slabs were used up
+}
+
+type slabSlot[kT, vT any] struct {
+	keys   []kT
+	values []vT
+}
+
+func newSlabStore[kT, vT any](slots, size int) *slabStore[kT, vT] {
+	return &slabStore[kT, vT]{
+		keys:   make([]kT, slots*size),
+		values: make([]vT, slots*size),
+	}
+}
+
+func (s *slabStore[kT, vT]) Alloc(capacity int) ([]kT, []vT) {
+	if n := len(s.free); n > 0 && cap(s.free[n-1].keys) >= capacity {
+		slot := s.free[n-1]
+		s.free = s.free[:n-1]
+		return slot.keys, slot.values
+	}
+	if len(s.keys) < capacity {
+		s.spills++
+		return make([]kT, 0, capacity), make([]vT, 0, capacity)
+	}
+	keys, values := s.keys[:0:capacity], s.values[:0:capacity]
+	s.keys, s.values = s.keys[capacity:], s.values[capacity:]
+	return keys, values
+}
+
+func (s *slabStore[kT, vT]) Free(keys []kT, values []vT) {
+	s.free = append(s.free, slabSlot[kT, vT]{keys: keys, values: values})
+}
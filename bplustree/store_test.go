@@ -0,0 +1,168 @@
+package bplustree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// arenaStore carves the slices of the leaves out of two preallocated arenas
+// split in fixed-size slots.
+type arenaStore struct {
+	size   int
+	keys   []int
+	values []int
+	free   []int        // free slots
+	live   map[*int]int // slot of each allocated key slice
+}
+
+func newArenaStore(slots, size int) *arenaStore {
+	s := &arenaStore{
+		size:   size,
+		keys:   make([]int, slots*size),
+		values: make([]int, slots*size),
+		live:   map[*int]int{},
+	}
+	for i := slots - 1; i >= 0; i-- {
+		s.free = append(s.free, i)
+	}
+	return s
+}
+
+func (s *arenaStore) Alloc(capacity int) ([]int, []int) {
+	if capacity > s.size || len(s.free) == 0 {
+		panic("arena exhausted")
+	}
+	slot := s.free[len(s.free)-1]
+	s.free = s.free[:len(s.free)-1]
+	lo, hi := slot*s.size, (slot+1)*s.size
+	keys := s.keys[lo:lo:hi]
+	s.live[&s.keys[lo]] = slot
+	return keys, s.values[lo:lo:hi]
+}
+
+func (s *arenaStore) Free(keys, values []int) {
+	if len(keys) != 0 || len(values) != 0 {
+		panic("freeing non-empty slices")
+	}
+	ptr := &keys[:1][0]
+	slot, ok := s.live[ptr]
+	if !ok {
+		panic("freeing slices not allocated by the arena")
+	}
+	delete(s.live, ptr)
+	s.free = append(s.free, slot)
+}
+
+// check verifies that every leaf of the tree lives in an allocated slot and
+// that no slot is leaked.
+func (s *arenaStore) check(tree *BPlusTree[int, int]) error {
+	leaves := 0
+	if tree.root != nil {
+		for n := tree.root.leftmostLeaf(); n != nil; n = n.next {
+			slot, ok := s.live[&n.keys[:1][0]]
+			if !ok || cap(n.keys) != s.size || &n.values[:1][0] != &s.values[slot*s.size] {
+				return fmt.Errorf("leaf %v is not backed by the arena", n.keys)
+			}
+			leaves++
+		}
+	}
+	if leaves != len(s.live) {
+		return fmt.Errorf("%d leaves for %d allocated slots", leaves, len(s.live))
+	}
+	return nil
+}
+
+func TestLeafStore(t *testing.T) {
+	const order = 4
+	store := newArenaStore(1000, order+1)
+	tree := New[int, int](order, intLess, WithLeafStore[int, int](store))
+	r := rand.New(rand.NewSource(9))
+	ref := map[int]int{}
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(400)
+		if r.Intn(3) == 0 {
+			tree.Remove(key)
+			delete(ref, key)
+		} else {
+			tree.Insert(key, key*10)
+			ref[key] = key * 10
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("op %d: %v", i, err)
+		}
+		if err := store.check(tree); err != nil {
+			t.Fatalf("op %d: %v", i, err)
+		}
+	}
+	for key, value := range ref {
+		if got, ok := tree.Get(key); !ok || got != value {
+			t.Fatalf("key %d: expect %d, got %d-%v", key, value, got, ok)
+		}
+	}
+
+	tree.Compact()
+	if err := store.check(tree); err != nil {
+		t.Fatalf("after compact: %v", err)
+	}
+	for key := range ref {
+		tree.Remove(key)
+	}
+	if err := store.check(tree); err != nil || len(store.live) != 0 {
+		t.Fatalf("expect every slot back in the arena, %d live: %v", len(store.live), err)
+	}
+}
+
+func TestNewWithHint(t *testing.T) {
+	for _, c := range []struct {
+		hint, entries int
+		spills        bool
+	}{
+		{hint: 5000, entries: 5000},
+		{hint: 100000, entries: 300},
+		{hint: 10, entries: 5000, spills: true},
+		{hint: 0, entries: 200, spills: true},
+		{hint: -1, entries: 200, spills: true},
+	} {
+		tree := NewWithHint[int, int](4, c.hint, intLess)
+		store := tree.store.(*slabStore[int, int])
+		r := rand.New(rand.NewSource(int64(c.hint)))
+		for _, key := range r.Perm(c.entries) {
+			tree.Insert(key, key*10)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("hint %d: %v", c.hint, err)
+		}
+		if tree.Len() != c.entries {
+			t.Fatalf("hint %d: expect %d entries, got %d", c.hint, c.entries, tree.Len())
+		}
+		if (store.spills > 0) != c.spills {
+			t.Fatalf("hint %d: %d entries spilled %d leaves", c.hint, c.entries, store.spills)
+		}
+		// Churn reuses the slices of the merged leaves.
+		spills := store.spills
+		for i := 0; i < 3; i++ {
+			for key := 0; key < c.entries; key += 2 {
+				tree.Remove(key)
+			}
+			for key := 0; key < c.entries; key += 2 {
+				tree.Insert(key, key*10)
+			}
+		}
+		if store.spills != spills {
+			t.Fatalf("hint %d: expect the freed slices reused, got %d more spills", c.hint, store.spills-spills)
+		}
+		for key := 0; key < c.entries; key++ {
+			if value, ok := tree.Get(key); !ok || value != key*10 {
+				t.Fatalf("hint %d: get %d got %d-%v", c.hint, key, value, ok)
+			}
+		}
+	}
+
+	store := newArenaStore(10, 5)
+	tree := NewWithHint[int, int](4, 1000, intLess, WithLeafStore[int, int](store))
+	tree.Insert(1, 1)
+	if err := store.check(tree); err != nil {
+		t.Fatalf("expect WithLeafStore to override the hint: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package bplustree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DumpTopology writes the exact node structure of the tree to w: the order,
+// then every node in pre-order with its keys, and its values for a leaf.
+// Unlike SaveFile, which writes the entries only, a tree restored by
+// RestoreTopology has the very same nodes with the same fill, so reproducing
+// a given shape doesn't depend on the insertion history. encKey and
+// encValue turn keys and values into their binary form.
+func (t *BPlusTree[kT, vT]) DumpTopology(w io.Writer, encKey func(kT) ([]byte, error), encValue func(vT) ([]byte, error)) error {
+	bw := bufio.NewWriter(w)
+	putUvarint(bw, uint64(t.order))
+	putUvarint(bw, uint64(t.length))
+	if t.root != nil {
+		if err := dumpNode(bw, t.root, encKey, encValue); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func dumpNode[kT, vT any](w *bufio.Writer, n *node[kT, vT], encKey func(kT) ([]byte, error), encValue func(vT) ([]byte, error)) error {
+	leaf := uint64(0)
+	if n.isLeaf {
+		leaf = 1
+	}
+	putUvarint(w, leaf)
+	putUvarint(w, uint64(len(n.keys)))
+	for _, key := range n.keys {
+		b, err := encKey(key)
+		if err != nil {
+			return err
+		}
+		putBytes(w, b)
+	}
+	if n.isLeaf {
+		for _, value := range n.values {
+			b, err := encValue(value)
+			if err != nil {
+				return err
+			}
+			putBytes(w, b)
+		}
+		return nil
+	}
+	for _, child := range n.children {
+		if err := dumpNode(w, child, encKey, encValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreTopology replaces the content of the tree with the nodes written by
+// DumpTopology, decKey and decValue turn the binary form back into keys and
+// values. The dump must come from a tree of the same order, otherwise the
+// returned error wraps ErrIncompatibleTrees. The restored nodes are checked
+// with Validate before they replace the content of the tree, on any error
+// the tree is left untouched.
+func (t *BPlusTree[kT, vT]) RestoreTopology(r io.Reader, decKey func([]byte) (kT, error), decValue func([]byte) (vT, error)) error {
+	br := bufio.NewReader(r)
+	order, err := binary.ReadUvarint(br)
+	if err != nil {
+		return err
+	}
+	if int(order) != t.order {
+		return fmt.Errorf("%w: topology of order %d, the tree has order %d", ErrIncompatibleTrees, order, t.order)
+	}
+	length, err := binary.ReadUvarint(br)
+	if err != nil {
+		return err
+	}
+	rs := &restorer[kT, vT]{t: t, r: br, decKey: decKey, decValue: decValue, leafDepth: -1}
+	var root *node[kT, vT]
+	if length > 0 {
+		root, err = rs.node(nil, 0)
+	}
+	if err == nil && rs.entries != int(length) {
+		err = fmt.Errorf("topology holds %d entries, expect %d", rs.entries, length)
+	}
+	if err == nil {
+		link(rs.leaves)
+		restored := &BPlusTree[kT, vT]{order: t.order, less: t.less, root: root, length: rs.entries, bloom: t.bloom}
+		err = restored.Validate()
+	}
+	if err != nil {
+		for _, n := range rs.leaves {
+			n.free()
+		}
+		return err
+	}
+	t.freeLeaves()
+	t.root, t.length = root, rs.entries
+	return nil
+}
+
+// restorer holds the state of a RestoreTopology in progress.
+type restorer[kT, vT any] struct {
+	t        *BPlusTree[kT, vT]
+	r        *bufio.Reader
+	decKey   func([]byte) (kT, error)
+	decValue func([]byte) (vT, error)
+
+	leaves    []*node[kT, vT] // in ascending order
+	leafDepth int
+	entries   int
+}
+
+func (rs *restorer[kT, vT]) node(parent *node[kT, vT], depth int) (*node[kT, vT], error) {
+	leaf, err := binary.ReadUvarint(rs.r)
+	if err != nil {
+		return nil, err
+	}
+	cnt, err := binary.ReadUvarint(rs.r)
+	if err != nil {
+		return nil, err
+	}
+	if cnt == 0 || cnt > uint64(rs.t.order) {
+		return nil, fmt.Errorf("node of %d keys in a tree of order %d", cnt, rs.t.order)
+	}
+	keys := make(items[kT], cnt)
+	for i := range keys {
+		b, err := readBytes(rs.r)
+		if err != nil {
+			return nil, err
+		}
+		if keys[i], err = rs.decKey(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if leaf == 0 {
+		n := &node[kT, vT]{order: rs.t.order, parent: parent, keys: keys, gen: rs.t.gen}
+		n.children = make(items[*node[kT, vT]], cnt+1)
+		for i := range n.children {
+			if n.children[i], err = rs.node(n, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	}
+
+	if rs.leafDepth >= 0 && depth != rs.leafDepth {
+		return nil, fmt.Errorf("leaves at depths %d and %d", rs.leafDepth, depth)
+	}
+	rs.leafDepth = depth
+	if len(rs.leaves) > 0 {
+		last := rs.leaves[len(rs.leaves)-1].keys
+		if !rs.t.less(last[len(last)-1], keys[0]) {
+			return nil, fmt.Errorf("leaf %d: %w", len(rs.leaves), ErrSortedInputRequired)
+		}
+	}
+	n := rs.t.newLeaf()
+	n.parent = parent
+	n.keys = append(n.keys, keys...)
+	n.bloomRebuild()
+	rs.leaves = append(rs.leaves, n)
+	for range keys {
+		b, err := readBytes(rs.r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := rs.decValue(b)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+	}
+	rs.entries += len(n.keys)
+	return n, nil
+}
+
+// putUvarint writes x in uvarint form, errors are reported by the final
+// Flush of w.
+func putUvarint(w *bufio.Writer, x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], x)
+	_, _ = w.Write(b[:n])
+}
+
+// putBytes writes the length of b in uvarint followed by b.
+func putBytes(w *bufio.Writer, b []byte) {
+	putUvarint(w, uint64(len(b)))
+	_, _ = w.Write(b)
+}
+
+// maxRecordSize bounds the size of a single key, value or entry read back
+// from a dump or a file, so a corrupt size can't make the reader allocate
+// an arbitrary amount of memory.
+const maxRecordSize = 1 << 24
+
+// checkRecordSize returns an error if a record size read from the input is
+// beyond maxRecordSize.
+func checkRecordSize(size uint64) error {
+	if size > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds the limit of %d bytes", size, maxRecordSize)
+	}
+	return nil
+}
+
+// readBytes reads a byte slice written by putBytes.
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRecordSize(size); err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,128 @@
+package bplustree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func encodeInt(v int) ([]byte, error) {
+	b := make([]byte, binary.MaxVarintLen64)
+	return b[:binary.PutVarint(b, int64(v))], nil
+}
+
+func decodeInt(b []byte) (int, error) {
+	v, n := binary.Varint(b)
+	if n <= 0 {
+		return 0, errors.New("malformed value")
+	}
+	return int(v), nil
+}
+
+func TestTopologyRoundTrip(t *testing.T) {
+	// random inserts and removals leave the nodes unevenly filled, unlike
+	// a BulkLoad.
+	tree := New[int, int](4, intLess)
+	r := rand.New(rand.NewSource(8))
+	for i := 0; i < 2000; i++ {
+		key := r.Intn(500)
+		if r.Intn(3) == 0 {
+			tree.Remove(key)
+		} else {
+			tree.Insert(key, key*10)
+		}
+	}
+
+	var dump bytes.Buffer
+	if err := tree.DumpTopology(&dump, encodeInt, encodeInt); err != nil {
+		t.Fatal(err)
+	}
+	restored := New[int, int](4, intLess)
+	if err := restored.RestoreTopology(bytes.NewReader(dump.Bytes()), decodeInt, decodeInt); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree.Stats(), restored.Stats()) {
+		t.Fatalf("expect stats %+v, got %+v", tree.Stats(), restored.Stats())
+	}
+	var again bytes.Buffer
+	if err := restored.DumpTopology(&again, encodeInt, encodeInt); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dump.Bytes(), again.Bytes()) {
+		t.Fatal("expect the restored tree to dump the same topology")
+	}
+
+	// the restored tree keeps working.
+	for key := 0; key < 500; key++ {
+		restored.Remove(key)
+		if err := restored.Validate(); err != nil {
+			t.Fatalf("remove %d: %v", key, err)
+		}
+	}
+}
+
+func TestTopologyEmpty(t *testing.T) {
+	var dump bytes.Buffer
+	if err := New[int, int](4, intLess).DumpTopology(&dump, encodeInt, encodeInt); err != nil {
+		t.Fatal(err)
+	}
+	tree := newIntTree(4, seq(0, 10, 1)...)
+	if err := tree.RestoreTopology(&dump, decodeInt, decodeInt); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Len() != 0 || tree.root != nil {
+		t.Fatalf("expect an empty tree, got %d entries", tree.Len())
+	}
+}
+
+func TestTopologyErrors(t *testing.T) {
+	var dump bytes.Buffer
+	if err := newIntTree(4, seq(0, 50, 1)...).DumpTopology(&dump, encodeInt, encodeInt); err != nil {
+		t.Fatal(err)
+	}
+	if err := New[int, int](5, intLess).RestoreTopology(bytes.NewReader(dump.Bytes()), decodeInt, decodeInt); !errors.Is(err, ErrIncompatibleTrees) {
+		t.Fatalf("expect ErrIncompatibleTrees on an order mismatch, got %v", err)
+	}
+	truncated := dump.Bytes()[:dump.Len()/2]
+	tree := newIntTree(4, 1, 2, 3)
+	if err := tree.RestoreTopology(bytes.NewReader(truncated), decodeInt, decodeInt); err == nil {
+		t.Fatal("expect an error on a truncated dump")
+	}
+	if !reflect.DeepEqual(keysOf(tree), []int{1, 2, 3}) {
+		t.Fatal("expect a failed restore to leave the tree untouched")
+	}
+
+	// order 4, two entries, a single leaf with its keys swapped
+	var swapped bytes.Buffer
+	w := bufio.NewWriter(&swapped)
+	putUvarint(w, 4)
+	putUvarint(w, 2)
+	putUvarint(w, 1)
+	putUvarint(w, 2)
+	for _, v := range []int{2, 1, 20, 10} {
+		b, _ := encodeInt(v)
+		putBytes(w, b)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.RestoreTopology(&swapped, decodeInt, decodeInt); err == nil {
+		t.Fatal("expect an error on keys out of order within a leaf")
+	}
+	if !reflect.DeepEqual(keysOf(tree), []int{1, 2, 3}) {
+		t.Fatal("expect a failed restore to leave the tree untouched")
+	}
+
+	// order 4, one entry, a leaf of one key claiming a huge size
+	huge := binary.AppendUvarint([]byte{4, 1, 1, 1}, 1<<62)
+	if err := tree.RestoreTopology(bytes.NewReader(huge), decodeInt, decodeInt); err == nil {
+		t.Fatal("expect an error on an oversized key")
+	}
+}
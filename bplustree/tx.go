@@ -0,0 +1,86 @@
+package bplustree
+
+// Tx buffers the writes of a transaction, see BPlusTree.Transaction.
+type Tx[kT, vT any] struct {
+	tree *BPlusTree[kT, vT]
+	// pending is the root of a subtree holding the buffered writes keyed
+	// by the written key in the same ordering as the tree, so the commit
+	// applies them in key order.
+	pending *node[kT, txWrite[vT]]
+}
+
+type txWrite[vT any] struct {
+	value   vT
+	removed bool
+}
+
+// Insert buffers the insertion of a key-value pair, it returns true if the
+// key exists in the view of the transaction. If the tree has a resolver, see
+// WithOnConflict, it is applied right away with the value of the key in the
+// view of the transaction as the existing one, so reads within the
+// transaction and the commit see the resolved value.
+func (tx *Tx[kT, vT]) Insert(key kT, value vT) bool {
+	existing, found := tx.Get(key)
+	if found && tx.tree.resolve != nil {
+		value = tx.tree.resolve(key, existing, value)
+	}
+	tx.buffer(key, txWrite[vT]{value: value})
+	return found
+}
+
+// Remove buffers the removal of a key, it returns the value the key has in
+// the view of the transaction and whether it exists.
+func (tx *Tx[kT, vT]) Remove(key kT) (vT, bool) {
+	value, found := tx.Get(key)
+	if found {
+		tx.buffer(key, txWrite[vT]{removed: true})
+	}
+	return value, found
+}
+
+// Get returns the value of a key as seen by the transaction, reflecting its
+// own buffered writes on top of the tree.
+func (tx *Tx[kT, vT]) Get(key kT) (_ vT, _ bool) {
+	n := tx.pending.findLeaf(key, tx.tree.less)
+	if index, ok := n.keys.find(key, tx.tree.less); ok {
+		if w := n.values[index]; !w.removed {
+			return w.value, true
+		}
+		return
+	}
+	return tx.tree.Get(key)
+}
+
+func (tx *Tx[kT, vT]) buffer(key kT, w txWrite[vT]) {
+	if root, _, _ := tx.pending.insert(key, w, tx.tree.less, nil); root != nil {
+		tx.pending = root
+	}
+}
+
+func (tx *Tx[kT, vT]) commit() {
+	for n := tx.pending.leftmostLeaf(); n != nil; n = n.next {
+		for i, key := range n.keys {
+			if w := n.values[i]; w.removed {
+				tx.tree.Remove(key)
+			} else {
+				tx.tree.insert(key, w.value, nil) // resolved when buffered
+			}
+		}
+	}
+}
+
+// Transaction runs fn with a transaction whose writes are buffered aside
+// from the tree. If fn returns an error (or panics), the buffered writes are
+// discarded and the tree is left untouched, otherwise they are all applied
+// to the tree before Transaction returns.
+func (t *BPlusTree[kT, vT]) Transaction(fn func(tx *Tx[kT, vT]) error) error {
+	tx := &Tx[kT, vT]{
+		tree:    t,
+		pending: &node[kT, txWrite[vT]]{order: t.order, isLeaf: true},
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	tx.commit()
+	return nil
+}
@@ -0,0 +1,103 @@
+package bplustree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	tree := newIntTree(4, seq(0, 20, 1)...)
+	errAbort := errors.New("abort")
+
+	err := tree.Transaction(func(tx *Tx[int, int]) error {
+		for key := 20; key < 30; key++ {
+			tx.Insert(key, key)
+		}
+		for key := 0; key < 10; key++ {
+			tx.Remove(key)
+		}
+		if _, ok := tx.Get(5); ok {
+			t.Fatalf("expect the transaction to see its own removal")
+		}
+		if value, ok := tx.Get(25); !ok || value != 25 {
+			t.Fatalf("expect the transaction to see its own insertion")
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("expect the error of fn to be returned, got %v", err)
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, seq(0, 20, 1)) {
+		t.Fatalf("expect the tree untouched after a failed transaction, got %v", got)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = tree.Transaction(func(tx *Tx[int, int]) error {
+		for key := 20; key < 30; key++ {
+			tx.Insert(key, key)
+		}
+		for key := 0; key < 10; key++ {
+			if _, ok := tx.Remove(key); !ok {
+				t.Fatalf("expect key %d to exist", key)
+			}
+		}
+		if _, ok := tx.Remove(100); ok {
+			t.Fatalf("expect key 100 to be absent")
+		}
+		tx.Remove(25)
+		tx.Insert(25, 250)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, seq(10, 30, 1)) {
+		t.Fatalf("expect the writes committed, got %v", got)
+	}
+	if value, _ := tree.Get(25); value != 250 {
+		t.Fatalf("expect the last write to win, got %d", value)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionOnConflict(t *testing.T) {
+	sum := func(_ int, existing, incoming int) int { return existing + incoming }
+	newTree := func() *BPlusTree[int, int] {
+		tree := New[int, int](4, intLess, WithOnConflict[int, int](sum))
+		for key := 0; key < 20; key++ {
+			tree.Insert(key, 10)
+		}
+		return tree
+	}
+	direct, txTree := newTree(), newTree()
+	writes := []int{1, 1, 30, 30, 5}
+	for _, key := range writes {
+		direct.Insert(key, 1)
+	}
+	err := txTree.Transaction(func(tx *Tx[int, int]) error {
+		for _, key := range writes {
+			tx.Insert(key, 1)
+		}
+		if value, _ := tx.Get(1); value != 12 {
+			t.Fatalf("expect the transaction to see the resolved 12, got %d", value)
+		}
+		if value, _ := tx.Get(30); value != 2 {
+			t.Fatalf("expect the transaction to see the resolved 2, got %d", value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []int{0, 1, 5, 30} {
+		expect, _ := direct.Get(key)
+		if got, _ := txTree.Get(key); got != expect {
+			t.Fatalf("key %d: expect %d as with direct inserts, got %d", key, expect, got)
+		}
+	}
+}
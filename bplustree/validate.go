@@ -0,0 +1,174 @@
+package bplustree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Validate checks the structural invariants of the tree: key ordering and
+// separator bounds, node fill, uniform leaf depth, parent pointers, the
+// leaf chain, the Bloom filters and the length counter. It returns an error
+// describing the first violation, or nil for a well-formed tree. It walks
+// the whole tree, so it costs O(n).
+func (t *BPlusTree[kT, vT]) Validate() error {
+	if t.root == nil {
+		if t.length != 0 {
+			return fmt.Errorf("empty tree with length %d", t.length)
+		}
+		return nil
+	}
+	if t.root.parent != nil {
+		return fmt.Errorf("root has a parent")
+	}
+	leafDepth := -1
+	var walk func(n *node[kT, vT], lo, hi *kT, depth int) error
+	walk = func(n *node[kT, vT], lo, hi *kT, depth int) error {
+		for i, key := range n.keys {
+			if i > 0 && !t.less(n.keys[i-1], key) {
+				return fmt.Errorf("keys out of order: %v", n.keys)
+			}
+			if lo != nil && t.less(key, *lo) {
+				return fmt.Errorf("key %v below separator %v", key, *lo)
+			}
+			if hi != nil && !t.less(key, *hi) {
+				return fmt.Errorf("key %v not below separator %v", key, *hi)
+			}
+		}
+		least := n.mergeKeys()
+		if n.appendSplit && n.next == nil {
+			least = 1 // see WithAppendOptimizedSplit
+		}
+		if n != t.root && len(n.keys) < least {
+			return fmt.Errorf("node underflow: %d keys, min %d", len(n.keys), least)
+		}
+		if len(n.keys) > n.maxKeys() {
+			return fmt.Errorf("node overflow: %d keys, max %d", len(n.keys), n.maxKeys())
+		}
+		if n.isLeaf {
+			if len(n.values) != len(n.keys) || len(n.children) != 0 {
+				return fmt.Errorf("malformed leaf")
+			}
+			if leafDepth == -1 {
+				leafDepth = depth
+			}
+			if depth != leafDepth {
+				return fmt.Errorf("leaves at depth %d and %d", leafDepth, depth)
+			}
+			if t.bloom != nil {
+				if n.bloom == nil {
+					return fmt.Errorf("leaf without a Bloom filter")
+				}
+				for _, key := range n.keys {
+					if !n.bloom.mayContain(key) {
+						return fmt.Errorf("leaf filter misses key %v", key)
+					}
+				}
+			}
+			return nil
+		}
+		if len(n.children) != len(n.keys)+1 || len(n.values) != 0 {
+			return fmt.Errorf("malformed internal node")
+		}
+		for i, child := range n.children {
+			if child.parent != n {
+				return fmt.Errorf("bad parent pointer")
+			}
+			clo, chi := lo, hi
+			if i > 0 {
+				clo = &n.keys[i-1]
+			}
+			if i < len(n.keys) {
+				chi = &n.keys[i]
+			}
+			if err := walk(child, clo, chi, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(t.root, nil, nil, 0); err != nil {
+		return err
+	}
+
+	cnt := 0
+	var prev *node[kT, vT]
+	for n := t.root.leftmostLeaf(); n != nil; prev, n = n, n.next {
+		if n.prev != prev {
+			return fmt.Errorf("broken prev pointer in the leaf chain")
+		}
+		cnt += len(n.keys)
+	}
+	if prev != t.root.rightmostLeaf() {
+		return fmt.Errorf("leaf chain does not end at the rightmost leaf")
+	}
+	if cnt != t.length {
+		return fmt.Errorf("leaf chain holds %d entries, length is %d", cnt, t.length)
+	}
+	return nil
+}
+
+// WithInvariantChecks makes the tree run Validate after every Insert and
+// removal and panic with the violation and the Print output of the tree on
+// the first one, so that a corrupting operation is caught at the exact call
+// that caused it. Every mutation then costs O(n) rather than O(log n), it is
+// meant for tests and debugging only and is off by default.
+func WithInvariantChecks[kT, vT any](enabled bool) Option[kT, vT] {
+	return func(t *BPlusTree[kT, vT]) {
+		t.checks = enabled
+	}
+}
+
+// check validates the tree after op if invariant checks are enabled.
+func (t *BPlusTree[kT, vT]) check(op string) {
+	if !t.checks {
+		return
+	}
+	if err := t.Validate(); err != nil {
+		var buf bytes.Buffer
+		_ = t.Print(&buf)
+		panic(fmt.Sprintf("invariant violated after %s: %v\n%s", op, err, buf.String()))
+	}
+}
+
+// RepairLeafChain relinks the next and prev pointers of the leaves in the
+// order a depth-first walk of the tree reaches them, and returns the number
+// of pointers it had to correct, 0 for a healthy tree. The leaf chain is
+// redundant with the tree structure, so a chain left inconsistent by a bug
+// can be rebuilt from it before relying on range scans. It costs O(n).
+func (t *BPlusTree[kT, vT]) RepairLeafChain() int {
+	if t.root == nil {
+		return 0
+	}
+	var leaves []*node[kT, vT]
+	var walk func(n *node[kT, vT])
+	walk = func(n *node[kT, vT]) {
+		if n.isLeaf {
+			leaves = append(leaves, n)
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(t.root)
+
+	cnt := 0
+	for i, n := range leaves {
+		var prev, next *node[kT, vT]
+		if i > 0 {
+			prev = leaves[i-1]
+		}
+		if i < len(leaves)-1 {
+			next = leaves[i+1]
+		}
+		if n.prev != prev {
+			n.prev = prev
+			cnt++
+		}
+		if n.next != next {
+			n.next = next
+			cnt++
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,32 @@
+package bplustree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepairLeafChain(t *testing.T) {
+	if n := New[int, int](4, intLess).RepairLeafChain(); n != 0 {
+		t.Fatalf("expect nothing to repair in an empty tree, got %d", n)
+	}
+	tree := newIntTree(4, seq(0, 100, 1)...)
+	if n := tree.RepairLeafChain(); n != 0 {
+		t.Fatalf("expect nothing to repair in a healthy tree, got %d", n)
+	}
+
+	// Skip the second leaf in the forward chain.
+	first := tree.root.leftmostLeaf()
+	first.next = first.next.next
+	if err := tree.Validate(); err == nil {
+		t.Fatal("expect the corrupted chain to fail validation")
+	}
+	if n := tree.RepairLeafChain(); n != 1 {
+		t.Fatalf("expect 1 pointer corrected, got %d", n)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := keysOf(tree); !reflect.DeepEqual(got, seq(0, 100, 1)) {
+		t.Fatalf("expect an ascending scan over every key, got %v", got)
+	}
+}
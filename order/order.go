@@ -0,0 +1,53 @@
+// Package order provides ready-made ordering functions for the trees in
+// this module, so callers don't need to hand-write them.
+package order
+
+// Ordered is a constraint that permits any type supporting the operators
+// < <= >= >.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Number is a constraint permitting the numeric types that can be summed
+// up, as the values aggregated by the trees.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Less reports whether 'a' < 'b', it can be used as the less function
+// of the AVL and B+ trees.
+func Less[T Ordered](a, b T) bool {
+	return a < b
+}
+
+// Compare returns -1 if 'a' < 'b', 0 if 'a' == 'b' and 1 if 'a' > 'b', it
+// can be used as the compare function of the red-black tree.
+func Compare[T Ordered](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if b < a {
+		return 1
+	}
+	return 0
+}
+
+// Reverse returns a less function ordering values the other way around.
+func Reverse[T any](less func(a, b T) bool) func(a, b T) bool {
+	return func(a, b T) bool {
+		return less(b, a)
+	}
+}
+
+// ByKey returns a less function ordering values by the key extracted
+// from each of them.
+func ByKey[T any, K Ordered](key func(T) K) func(a, b T) bool {
+	return func(a, b T) bool {
+		return key(a) < key(b)
+	}
+}
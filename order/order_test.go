@@ -0,0 +1,66 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/maxnilz/tree/avltree"
+	"github.com/maxnilz/tree/bplustree"
+	"github.com/maxnilz/tree/order"
+	"github.com/maxnilz/tree/rbtree"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		a, b   int
+		expect int
+	}{
+		{a: 1, b: 2, expect: -1},
+		{a: 2, b: 2, expect: 0},
+		{a: 3, b: 2, expect: 1},
+	}
+	for _, c := range cases {
+		if got := order.Compare(c.a, c.b); got != c.expect {
+			t.Fatalf("Compare(%d, %d) expect %d, got %d", c.a, c.b, c.expect, got)
+		}
+		if got := order.Less(c.a, c.b); got != (c.expect < 0) {
+			t.Fatalf("Less(%d, %d) got %v", c.a, c.b, got)
+		}
+		if got := order.Reverse(order.Less[int])(c.a, c.b); got != (c.expect > 0) {
+			t.Fatalf("Reverse(Less)(%d, %d) got %v", c.a, c.b, got)
+		}
+	}
+}
+
+func TestByKey(t *testing.T) {
+	type user struct {
+		id   int
+		name string
+	}
+	less := order.ByKey(func(u user) string { return u.name })
+	if !less(user{id: 2, name: "a"}, user{id: 1, name: "b"}) {
+		t.Fatalf("expect ordering by name")
+	}
+	if less(user{id: 1, name: "a"}, user{id: 2, name: "a"}) {
+		t.Fatalf("expect equal names to be unordered")
+	}
+}
+
+func TestTreesAcceptHelpers(t *testing.T) {
+	avl := avltree.New[int](order.Reverse(order.Less[int]))
+	rb := rbtree.New[int](order.Compare[int])
+	bpt := bplustree.New[int, int](4, order.Less[int])
+	for i := 0; i < 10; i++ {
+		avl.Insert(i)
+		rb.Insert(i)
+		bpt.Insert(i, i)
+	}
+	if min, _ := avl.Min(); min != 9 {
+		t.Fatalf("expect reversed avl min 9, got %d", min)
+	}
+	if min, _ := rb.Min(); min != 0 {
+		t.Fatalf("expect rb min 0, got %d", min)
+	}
+	if min, _, _ := bpt.Min(); min != 0 {
+		t.Fatalf("expect b+ tree min 0, got %d", min)
+	}
+}
@@ -0,0 +1,109 @@
+// Package ordered defines the set-like operations shared by the trees in
+// this module, so callers can swap one implementation for another behind a
+// single interface.
+package ordered
+
+import (
+	"github.com/maxnilz/tree/avltree"
+	"github.com/maxnilz/tree/bplustree"
+	"github.com/maxnilz/tree/rbtree"
+)
+
+// Tree is an ordered set of values of type 'T'.
+type Tree[T any] interface {
+	// Insert adds the value, it returns false if an equal value exists already.
+	Insert(value T) bool
+	// Remove removes the value, it returns false if no equal value exists.
+	Remove(value T) (T, bool)
+	// Contains reports whether an equal value exists.
+	Contains(value T) bool
+	// Len returns the number of values.
+	Len() int
+	// Min returns the smallest value, or false if the tree is empty.
+	Min() (T, bool)
+	// Max returns the largest value, or false if the tree is empty.
+	Max() (T, bool)
+	// InOrder visits the values in ascending order until visit returns false.
+	InOrder(visit func(T) bool)
+}
+
+// AVL returns the AVL tree as a Tree.
+func AVL[T any](t *avltree.AVLTree[T]) Tree[T] {
+	return t
+}
+
+// RB returns the red-black tree as a Tree.
+func RB[T any](t *rbtree.RBTree[T]) Tree[T] {
+	return t
+}
+
+// BPlus returns a Tree backed by the B+ tree using the values as keys, the
+// stored values are ignored.
+func BPlus[T any](t *bplustree.BPlusTree[T, struct{}]) Tree[T] {
+	return &bplusSet[T]{tree: t}
+}
+
+type bplusSet[T any] struct {
+	tree *bplustree.BPlusTree[T, struct{}]
+}
+
+func (s *bplusSet[T]) Insert(value T) bool {
+	return !s.tree.Insert(value, struct{}{})
+}
+
+func (s *bplusSet[T]) Remove(value T) (_ T, _ bool) {
+	if _, ok := s.tree.Remove(value); ok {
+		return value, true
+	}
+	return
+}
+
+func (s *bplusSet[T]) Contains(value T) bool {
+	_, ok := s.tree.Get(value)
+	return ok
+}
+
+func (s *bplusSet[T]) Len() int {
+	return s.tree.Len()
+}
+
+func (s *bplusSet[T]) Min() (T, bool) {
+	key, _, ok := s.tree.Min()
+	return key, ok
+}
+
+func (s *bplusSet[T]) Max() (T, bool) {
+	key, _, ok := s.tree.Max()
+	return key, ok
+}
+
+func (s *bplusSet[T]) InOrder(visit func(T) bool) {
+	s.tree.Ascend(func(key T, _ struct{}) bool {
+		return visit(key)
+	})
+}
+
+// ToRBTree returns a red-black tree holding the values of the AVL tree,
+// ordered by compare which must agree with the ordering of the AVL tree.
+// The values are collected by an in-order walk and laid out in O(n) by
+// rbtree.BuildFromSorted rather than inserted one by one.
+func ToRBTree[T any](a *avltree.AVLTree[T], compare rbtree.CompareFunc[T]) *rbtree.RBTree[T] {
+	return rbtree.BuildFromSorted(compare, collect[T](a))
+}
+
+// ToAVL returns an AVL tree holding the values of the red-black tree,
+// ordered by less which must agree with the ordering of the red-black tree,
+// see ToRBTree.
+func ToAVL[T any](t *rbtree.RBTree[T], less avltree.LessFunc[T]) *avltree.AVLTree[T] {
+	return avltree.BuildFromSorted(less, collect[T](t))
+}
+
+// collect returns the values of the tree in ascending order.
+func collect[T any](t Tree[T]) []T {
+	out := make([]T, 0, t.Len())
+	t.InOrder(func(value T) bool {
+		out = append(out, value)
+		return true
+	})
+	return out
+}
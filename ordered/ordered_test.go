@@ -0,0 +1,133 @@
+package ordered
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/maxnilz/tree/avltree"
+	"github.com/maxnilz/tree/bplustree"
+	"github.com/maxnilz/tree/rbtree"
+)
+
+func TestSameOperationSequence(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	compare := func(a, b int) int { return a - b }
+	trees := map[string]Tree[int]{
+		"avl":   AVL(avltree.New[int](less)),
+		"rb":    RB(rbtree.New[int](compare)),
+		"bplus": BPlus(bplustree.New[int, struct{}](4, less)),
+	}
+
+	r := rand.New(rand.NewSource(1))
+	ref := map[int]bool{}
+	for i := 0; i < 5000; i++ {
+		value := r.Intn(300)
+		insert := r.Intn(2) == 0
+		for name, tree := range trees {
+			if insert {
+				if got := tree.Insert(value); got == ref[value] {
+					t.Fatalf("%s: step %d insert %d returned %v", name, i, value, got)
+				}
+				continue
+			}
+			if _, got := tree.Remove(value); got != ref[value] {
+				t.Fatalf("%s: step %d remove %d returned %v", name, i, value, got)
+			}
+		}
+		if insert {
+			ref[value] = true
+		} else {
+			delete(ref, value)
+		}
+	}
+
+	var expect []int
+	for value := range ref {
+		expect = append(expect, value)
+	}
+	sort.Ints(expect)
+	for name, tree := range trees {
+		var got []int
+		tree.InOrder(func(value int) bool {
+			got = append(got, value)
+			return true
+		})
+		if !reflect.DeepEqual(got, expect) {
+			t.Fatalf("%s: in-order mismatch\nexpect %v\ngot    %v", name, expect, got)
+		}
+		if tree.Len() != len(expect) {
+			t.Fatalf("%s: expect len %d, got %d", name, len(expect), tree.Len())
+		}
+		if min, ok := tree.Min(); !ok || min != expect[0] {
+			t.Fatalf("%s: expect min %d, got %d", name, expect[0], min)
+		}
+		if max, ok := tree.Max(); !ok || max != expect[len(expect)-1] {
+			t.Fatalf("%s: expect max %d, got %d", name, expect[len(expect)-1], max)
+		}
+		for value := 0; value < 300; value++ {
+			if tree.Contains(value) != ref[value] {
+				t.Fatalf("%s: contains %d mismatch", name, value)
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	trees := map[string]Tree[int]{
+		"avl":   AVL(avltree.New[int](less)),
+		"rb":    RB(rbtree.New[int](func(a, b int) int { return a - b })),
+		"bplus": BPlus(bplustree.New[int, struct{}](4, less)),
+	}
+	for name, tree := range trees {
+		if _, ok := tree.Min(); ok {
+			t.Fatalf("%s: min on empty tree", name)
+		}
+		if _, ok := tree.Max(); ok {
+			t.Fatalf("%s: max on empty tree", name)
+		}
+		if _, ok := tree.Remove(1); ok {
+			t.Fatalf("%s: remove on empty tree", name)
+		}
+		if tree.Len() != 0 || tree.Contains(1) {
+			t.Fatalf("%s: empty tree is not empty", name)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	compare := func(a, b int) int { return a - b }
+	a := avltree.New[int](less)
+	for _, value := range rand.New(rand.NewSource(2)).Perm(500) {
+		a.Insert(value * 3)
+	}
+	expect := collect[int](a)
+
+	r := ToRBTree(a, compare)
+	if got := collect[int](r); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect the red-black tree to hold %v, got %v", expect, got)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("expect a valid red-black tree: %v", err)
+	}
+	back := ToAVL(r, less)
+	if err := back.Validate(); err != nil {
+		t.Fatalf("expect a valid AVL tree: %v", err)
+	}
+	if !back.Equal(a, func(x, y int) bool { return x == y }) {
+		t.Fatal("expect the round trip to give back an equal AVL tree")
+	}
+	// both keep working as usual trees.
+	for _, tree := range []Tree[int]{r, back} {
+		if !tree.Insert(1) || tree.Insert(3) || tree.Len() != 501 {
+			t.Fatal("expect the converted tree to accept inserts")
+		}
+	}
+
+	if ToRBTree(avltree.New[int](less), compare).Len() != 0 || ToAVL(rbtree.New[int](compare), less).Len() != 0 {
+		t.Fatal("expect empty trees to convert to empty trees")
+	}
+}
@@ -0,0 +1,59 @@
+package queue
+
+// ChannelQueue is a bounded queue safe for concurrent use, backed by a
+// buffered channel, for producer-consumer pipelines. Unlike Queue, pushing
+// to a full queue and popping from an empty one block until they can
+// proceed, TryPushBack and TryPopFront are the non-blocking variants.
+type ChannelQueue[T any] struct {
+	items chan T
+}
+
+// NewChannelQueue returns a queue holding at most capacity items.
+func NewChannelQueue[T any](capacity int) *ChannelQueue[T] {
+	return &ChannelQueue[T]{items: make(chan T, capacity)}
+}
+
+// PushBack pushes the item to the back of the queue, waiting for room if
+// the queue is full.
+func (q *ChannelQueue[T]) PushBack(item T) {
+	q.items <- item
+}
+
+// TryPushBack pushes the item to the back of the queue, it returns false
+// and leaves the queue untouched if the queue is full.
+func (q *ChannelQueue[T]) TryPushBack(item T) bool {
+	select {
+	case q.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// PopFront removes and returns the front item, waiting for one if the queue
+// is empty.
+func (q *ChannelQueue[T]) PopFront() T {
+	return <-q.items
+}
+
+// TryPopFront removes and returns the front item, it returns false if the
+// queue is empty.
+func (q *ChannelQueue[T]) TryPopFront() (_ T, _ bool) {
+	select {
+	case item := <-q.items:
+		return item, true
+	default:
+		return
+	}
+}
+
+// Size returns the number of items in the queue, which may have changed by
+// the time it returns if other goroutines use the queue.
+func (q *ChannelQueue[T]) Size() int {
+	return len(q.items)
+}
+
+// Capacity returns the most items the queue holds.
+func (q *ChannelQueue[T]) Capacity() int {
+	return cap(q.items)
+}
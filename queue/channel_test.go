@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestChannelQueue(t *testing.T) {
+	q := NewChannelQueue[int](2)
+	if _, ok := q.TryPopFront(); ok {
+		t.Fatal("expect TryPopFront on an empty queue to fail")
+	}
+	if !q.TryPushBack(1) || !q.TryPushBack(2) || q.TryPushBack(3) {
+		t.Fatal("expect TryPushBack to fail once the queue is full")
+	}
+	if q.Size() != 2 || q.Capacity() != 2 {
+		t.Fatalf("expect size 2 of capacity 2, got %d of %d", q.Size(), q.Capacity())
+	}
+	if item, ok := q.TryPopFront(); !ok || item != 1 {
+		t.Fatalf("expect 1, got %d-%v", item, ok)
+	}
+	if item := q.PopFront(); item != 2 {
+		t.Fatalf("expect 2, got %d", item)
+	}
+}
+
+func TestChannelQueueConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 4, 3, 1000
+	q := NewChannelQueue[int](8)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.PushBack(p*perProducer + i)
+			}
+		}(p)
+	}
+
+	seen := make([][]int, consumers)
+	var consumed sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		consumed.Add(1)
+		go func(c int) {
+			defer consumed.Done()
+			for {
+				item := q.PopFront()
+				if item < 0 {
+					return
+				}
+				seen[c] = append(seen[c], item)
+			}
+		}(c)
+	}
+	wg.Wait()
+	for c := 0; c < consumers; c++ {
+		q.PushBack(-1) // one stop marker per consumer
+	}
+	consumed.Wait()
+
+	counts := make(map[int]int)
+	for _, items := range seen {
+		for _, item := range items {
+			counts[item]++
+		}
+	}
+	if len(counts) != producers*perProducer {
+		t.Fatalf("expect %d distinct items, got %d", producers*perProducer, len(counts))
+	}
+	for item, cnt := range counts {
+		if cnt != 1 {
+			t.Fatalf("item %d consumed %d times", item, cnt)
+		}
+	}
+}
@@ -1,5 +1,13 @@
 package queue
 
+import (
+	"errors"
+	"iter"
+)
+
+// ErrFull is the value PushBack panics with on a full bounded queue.
+var ErrFull = errors.New("queue: full")
+
 type items[T any] []T
 
 // insertAt inserts a value into the given index, pushing all subsequent values
@@ -24,18 +32,82 @@ func (s *items[T]) removeAt(index int) T {
 	return item
 }
 
+// truncate truncates this instance at index so that it contains only the
+// first index items. index must be less than or equal to length.
+func (s *items[T]) truncate(index int) {
+	var toClear items[T]
+	*s, toClear = (*s)[:index], (*s)[index:]
+	var zero T
+	for i := 0; i < len(toClear); i++ {
+		toClear[i] = zero
+	}
+}
+
 type Queue[T any] interface {
+	// PopFront removes and returns the front item, or the zero value if
+	// the queue is empty. The vacated slot of the backing store is zeroed,
+	// so a popped pointer doesn't stay reachable from the queue until the
+	// slot gets reused.
 	PopFront() T
+	// PushBack pushes the item to the back of the queue, it panics with
+	// ErrFull if the queue is full.
 	PushBack(item T)
+	// TryPushBack pushes the item to the back of the queue, it returns
+	// false and leaves the queue untouched if the queue is full.
+	TryPushBack(item T) bool
 	Size() int
+	// IsEmpty reports whether there are no items in the queue.
+	IsEmpty() bool
+	// Clear drops all items but keeps the allocated backing store,
+	// so the queue can be reused without growing it again. The slots are
+	// zeroed, like the one vacated by PopFront.
+	Clear()
+	// ToSlice returns a copy of the items in front-to-back order.
+	ToSlice() []T
+	// ForEach visits the items in front-to-back order without
+	// dequeuing them, it stops once visit returns false.
+	ForEach(visit func(T) bool)
+	// At returns the i-th item from the front without dequeuing it, or
+	// false if i is out of range.
+	At(i int) (T, bool)
+	// Clone returns an independent queue holding a copy of the items in
+	// the same order, with the same bound if any.
+	Clone() Queue[T]
+	// Drain removes all items and returns them in front-to-back order,
+	// leaving the queue empty.
+	Drain() []T
+	// Reverse reverses the front-to-back order of the items in place.
+	Reverse()
+	// Consume returns an iterator popping the items from the front as the
+	// caller ranges over it, until the queue is empty. Breaking out of the
+	// loop leaves the items not reached yet in the queue.
+	Consume() iter.Seq[T]
 }
 
 func New[T any]() Queue[T] {
 	return &queue[T]{}
 }
 
+// NewWithCapacity returns an unbounded queue whose backing store is
+// preallocated for capacity items, so pushing up to that many items
+// doesn't reallocate.
+func NewWithCapacity[T any](capacity int) Queue[T] {
+	return &queue[T]{items: make(items[T], 0, capacity)}
+}
+
+// NewBounded returns a queue holding at most capacity items. Once it is
+// full, TryPushBack reports false and PushBack panics, so callers that
+// expect overflow should use TryPushBack.
+func NewBounded[T any](capacity int) Queue[T] {
+	return &queue[T]{bounded: true, capacity: capacity}
+}
+
 type queue[T any] struct {
 	items items[T]
+
+	// bounded only
+	bounded  bool
+	capacity int
 }
 
 func (q *queue[T]) PopFront() (_ T) {
@@ -46,10 +118,75 @@ func (q *queue[T]) PopFront() (_ T) {
 }
 
 func (q *queue[T]) PushBack(item T) {
+	if !q.TryPushBack(item) {
+		panic(ErrFull)
+	}
+}
+
+func (q *queue[T]) TryPushBack(item T) bool {
+	if q.bounded && len(q.items) >= q.capacity {
+		return false
+	}
 	idx := len(q.items)
 	q.items.insertAt(idx, item)
+	return true
 }
 
 func (q *queue[T]) Size() int {
 	return len(q.items)
 }
+
+func (q *queue[T]) IsEmpty() bool {
+	return len(q.items) == 0
+}
+
+func (q *queue[T]) Clear() {
+	q.items.truncate(0)
+}
+
+func (q *queue[T]) ToSlice() []T {
+	out := make([]T, len(q.items))
+	copy(out, q.items)
+	return out
+}
+
+func (q *queue[T]) ForEach(visit func(T) bool) {
+	for _, item := range q.items {
+		if !visit(item) {
+			return
+		}
+	}
+}
+
+func (q *queue[T]) At(i int) (_ T, _ bool) {
+	if i < 0 || i >= len(q.items) {
+		return
+	}
+	return q.items[i], true
+}
+
+func (q *queue[T]) Clone() Queue[T] {
+	return &queue[T]{items: q.ToSlice(), bounded: q.bounded, capacity: q.capacity}
+}
+
+func (q *queue[T]) Drain() []T {
+	out := q.items
+	q.items = nil
+	return out
+}
+
+func (q *queue[T]) Reverse() {
+	for i, j := 0, len(q.items)-1; i < j; i, j = i+1, j-1 {
+		q.items[i], q.items[j] = q.items[j], q.items[i]
+	}
+}
+
+func (q *queue[T]) Consume() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for len(q.items) > 0 {
+			if !yield(q.PopFront()) {
+				return
+			}
+		}
+	}
+}
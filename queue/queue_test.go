@@ -0,0 +1,319 @@
+package queue
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestToSliceAndForEach(t *testing.T) {
+	q := New[int]()
+	if got := q.ToSlice(); len(got) != 0 {
+		t.Fatalf("expect empty slice, got %v", got)
+	}
+	// interleave pushes and pops so the front is no longer the first pushed item.
+	for i := 0; i < 5; i++ {
+		q.PushBack(i)
+	}
+	q.PopFront()
+	q.PopFront()
+	for i := 5; i < 8; i++ {
+		q.PushBack(i)
+	}
+
+	expect := []int{2, 3, 4, 5, 6, 7}
+	got := q.ToSlice()
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+	got[0] = 100
+	if front := q.PopFront(); front != 2 {
+		t.Fatalf("mutating the slice changed the queue, front is %d", front)
+	}
+	q.PushBack(8)
+
+	var visited []int
+	q.ForEach(func(item int) bool {
+		visited = append(visited, item)
+		return true
+	})
+	expect = []int{3, 4, 5, 6, 7, 8}
+	if !reflect.DeepEqual(visited, expect) {
+		t.Fatalf("expect %v, got %v", expect, visited)
+	}
+	if q.Size() != len(expect) {
+		t.Fatalf("ForEach dequeued items, size %d", q.Size())
+	}
+
+	visited = visited[:0]
+	q.ForEach(func(item int) bool {
+		visited = append(visited, item)
+		return len(visited) < 2
+	})
+	if !reflect.DeepEqual(visited, []int{3, 4}) {
+		t.Fatalf("expect early stop after 2 items, got %v", visited)
+	}
+}
+
+func TestBounded(t *testing.T) {
+	q := NewBounded[int](3)
+	for i := 0; i < 3; i++ {
+		if !q.TryPushBack(i) {
+			t.Fatalf("push %d rejected before reaching capacity", i)
+		}
+	}
+	if q.TryPushBack(3) {
+		t.Fatalf("push accepted beyond capacity")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != ErrFull {
+				t.Fatalf("expect PushBack to panic with ErrFull on overflow, got %v", r)
+			}
+		}()
+		q.PushBack(4)
+	}()
+	if q.Size() != 3 {
+		t.Fatalf("expect size 3 after overflow, got %d", q.Size())
+	}
+	if front := q.PopFront(); front != 0 {
+		t.Fatalf("expect front 0, got %d", front)
+	}
+	if !q.TryPushBack(5) {
+		t.Fatalf("push rejected after popping one")
+	}
+	expect := []int{1, 2, 5}
+	if got := q.ToSlice(); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+
+	unbounded := New[int]()
+	for i := 0; i < 100; i++ {
+		if !unbounded.TryPushBack(i) {
+			t.Fatalf("unbounded queue rejected push %d", i)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	q := New[int]()
+	if _, ok := q.At(0); ok {
+		t.Fatal("expect At on an empty queue to fail")
+	}
+	for i := 0; i < 6; i++ {
+		q.PushBack(i)
+	}
+	q.PopFront()
+	for i := 0; i < q.Size(); i++ {
+		if got, ok := q.At(i); !ok || got != i+1 {
+			t.Fatalf("At(%d) expect %d, got %d-%v", i, i+1, got, ok)
+		}
+	}
+	for _, i := range []int{-1, 5, 10} {
+		if _, ok := q.At(i); ok {
+			t.Fatalf("At(%d) expect out of range", i)
+		}
+	}
+	if q.Size() != 5 {
+		t.Fatalf("expect At not to dequeue, got size %d", q.Size())
+	}
+}
+
+func TestClone(t *testing.T) {
+	q := New[int]()
+	for i := 0; i < 4; i++ {
+		q.PushBack(i)
+	}
+	c := q.Clone()
+	if !reflect.DeepEqual(c.ToSlice(), q.ToSlice()) {
+		t.Fatalf("expect clone %v, got %v", q.ToSlice(), c.ToSlice())
+	}
+	c.PopFront()
+	c.PushBack(10)
+	if expect := []int{0, 1, 2, 3}; !reflect.DeepEqual(q.ToSlice(), expect) {
+		t.Fatalf("mutating the clone changed the original to %v", q.ToSlice())
+	}
+	q.PushBack(4)
+	if expect := []int{1, 2, 3, 10}; !reflect.DeepEqual(c.ToSlice(), expect) {
+		t.Fatalf("mutating the original changed the clone to %v", c.ToSlice())
+	}
+
+	b := NewBounded[int](2)
+	b.PushBack(1)
+	bc := b.Clone()
+	if !bc.TryPushBack(2) || bc.TryPushBack(3) {
+		t.Fatal("expect the clone to keep the bound")
+	}
+	if b.Size() != 1 {
+		t.Fatalf("expect the original untouched, got size %d", b.Size())
+	}
+}
+
+func TestDrain(t *testing.T) {
+	q := New[int]()
+	if got := q.Drain(); len(got) != 0 {
+		t.Fatalf("expect nothing drained from an empty queue, got %v", got)
+	}
+	for i := 0; i < 6; i++ {
+		q.PushBack(i)
+	}
+	q.PopFront()
+	q.PushBack(6)
+	got := q.Drain()
+	if expect := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+	if !q.IsEmpty() || q.Size() != 0 {
+		t.Fatalf("expect an empty queue after draining, got size %d", q.Size())
+	}
+	q.PushBack(7)
+	if got[0] != 1 {
+		t.Fatal("expect pushing after a drain not to touch the drained slice")
+	}
+	if front := q.PopFront(); front != 7 {
+		t.Fatalf("expect the queue to keep working after a drain, got %d", front)
+	}
+}
+
+func TestClear(t *testing.T) {
+	q := New[int]()
+	if !q.IsEmpty() {
+		t.Fatal("expect a new queue to be empty")
+	}
+	for i := 0; i < 10; i++ {
+		q.PushBack(i)
+	}
+	if q.IsEmpty() {
+		t.Fatal("expect a filled queue not to be empty")
+	}
+	capacity := cap(q.(*queue[int]).items)
+	q.Clear()
+	if !q.IsEmpty() || q.Size() != 0 {
+		t.Fatalf("expect an empty queue after clearing, got size %d", q.Size())
+	}
+	if got := cap(q.(*queue[int]).items); got != capacity {
+		t.Fatalf("expect the capacity %d kept after clearing, got %d", capacity, got)
+	}
+	q.PushBack(10)
+	if front := q.PopFront(); front != 10 {
+		t.Fatalf("expect the queue to keep working after a clear, got %d", front)
+	}
+}
+
+func TestConsume(t *testing.T) {
+	q := New[int]()
+	for range q.Consume() {
+		t.Fatal("expect nothing consumed from an empty queue")
+	}
+	for i := 0; i < 6; i++ {
+		q.PushBack(i)
+	}
+	var got []int
+	for item := range q.Consume() {
+		got = append(got, item)
+		if item == 2 {
+			break
+		}
+	}
+	if expect := []int{0, 1, 2}; !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v consumed before the break, got %v", expect, got)
+	}
+	if expect := []int{3, 4, 5}; !reflect.DeepEqual(q.ToSlice(), expect) {
+		t.Fatalf("expect %v left after the break, got %v", expect, q.ToSlice())
+	}
+
+	got = got[:0]
+	for item := range q.Consume() {
+		got = append(got, item)
+		if item == 3 {
+			q.PushBack(6) // pushed while ranging, consumed in turn
+		}
+	}
+	if expect := []int{3, 4, 5, 6}; !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v consumed, got %v", expect, got)
+	}
+	if !q.IsEmpty() {
+		t.Fatalf("expect an empty queue after consuming, got size %d", q.Size())
+	}
+}
+
+func TestReverse(t *testing.T) {
+	q := New[int]()
+	q.Reverse()
+	if !q.IsEmpty() {
+		t.Fatal("expect an empty queue to stay empty")
+	}
+	q.PushBack(1)
+	q.Reverse()
+	if got := q.ToSlice(); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("expect a single item untouched, got %v", got)
+	}
+	for i := 2; i <= 6; i++ {
+		q.PushBack(i)
+	}
+	q.PopFront()
+	q.PopFront()
+	q.PushBack(7)
+	q.Reverse()
+	if expect := []int{7, 6, 5, 4, 3}; !reflect.DeepEqual(q.ToSlice(), expect) {
+		t.Fatalf("expect %v, got %v", expect, q.ToSlice())
+	}
+	q.PushBack(8)
+	if front := q.PopFront(); front != 7 {
+		t.Fatalf("expect the reversed order to hold for pops, got %d", front)
+	}
+	if expect := []int{6, 5, 4, 3, 8}; !reflect.DeepEqual(q.ToSlice(), expect) {
+		t.Fatalf("expect %v, got %v", expect, q.ToSlice())
+	}
+}
+
+func TestNewWithCapacity(t *testing.T) {
+	q := NewWithCapacity[int](16)
+	items := q.(*queue[int]).items
+	backing := &items[:cap(items)][0]
+	for i := 0; i < 16; i++ {
+		q.PushBack(i)
+		if items := q.(*queue[int]).items; &items[0] != backing {
+			t.Fatalf("expect no reallocation up to the capacity, got one at push %d", i)
+		}
+	}
+	if q.Size() != 16 {
+		t.Fatalf("expect size 16, got %d", q.Size())
+	}
+}
+
+func TestPopFrontReleasesItem(t *testing.T) {
+	type payload struct{ data [1 << 10]byte }
+	var freed int32
+	track := func() *payload {
+		p := &payload{}
+		runtime.SetFinalizer(p, func(*payload) { atomic.AddInt32(&freed, 1) })
+		return p
+	}
+	q := NewWithCapacity[*payload](8)
+	for i := 0; i < 4; i++ {
+		q.PushBack(track())
+	}
+	q.PopFront()
+	q.PopFront()
+	q.PushBack(&payload{})
+	for i := 0; i < 50 && atomic.LoadInt32(&freed) < 2; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if cnt := atomic.LoadInt32(&freed); cnt != 2 {
+		t.Fatalf("expect the 2 popped items collected while the queue is alive, got %d", cnt)
+	}
+
+	q.Clear()
+	for i := 0; i < 50 && atomic.LoadInt32(&freed) < 4; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if cnt := atomic.LoadInt32(&freed); cnt != 4 {
+		t.Fatalf("expect the cleared items collected, got %d", cnt)
+	}
+	runtime.KeepAlive(q)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"github.com/maxnilz/tree/order"
 	"github.com/maxnilz/tree/rbtree"
 	"log"
 	"os"
@@ -10,16 +11,7 @@ import (
 )
 
 func main() {
-	compare := func(a, b int) int {
-		if a == b {
-			return 0
-		}
-		if a < b {
-			return -1
-		}
-		return 1
-	}
-	tree := rbtree.New[int](compare)
+	tree := rbtree.New[int](order.Compare[int])
 	value := 0
 	reader := bufio.NewReader(os.Stdin)
 	for {
@@ -0,0 +1,124 @@
+package rbtree
+
+// Iterator walks the items of a tree in either direction from a position
+// set by First, Last or Seek. It steps along the parent links of the nodes,
+// so moving costs O(1) amortized and the iterator holds no state beyond the
+// current node. An iterator is invalidated by any mutation of the tree.
+type Iterator[T any] struct {
+	tree *RBTree[T]
+	n    *node[T] // the current node, nil if invalid
+}
+
+// Iterator returns an iterator of the tree, it isn't positioned until First,
+// Last or Seek is called.
+func (t *RBTree[T]) Iterator() *Iterator[T] {
+	return &Iterator[T]{tree: t}
+}
+
+// Find returns an iterator positioned at the item equal to the given one and
+// true, or, if there is none, at the first greater item and false, so a
+// lookup can go on with a scan around it without a second descent. The
+// iterator is invalid if the item is missing and greater than all others.
+func (t *RBTree[T]) Find(item T) (*Iterator[T], bool) {
+	it := t.Iterator()
+	if !it.Seek(item) {
+		return it, false
+	}
+	return it, t.compare(it.Item(), item) == 0
+}
+
+// Valid reports whether the iterator is positioned at an item. Once Next or
+// Prev moves past either end, the iterator stays invalid until it is
+// repositioned.
+func (it *Iterator[T]) Valid() bool {
+	return it.n != nil
+}
+
+// Item returns the current item, the iterator must be valid.
+func (it *Iterator[T]) Item() T {
+	return it.n.data
+}
+
+// First moves the iterator to the smallest item and reports whether there is
+// one.
+func (it *Iterator[T]) First() bool {
+	it.n = extreme(it.tree.root, leftDir)
+	return it.Valid()
+}
+
+// Last moves the iterator to the largest item and reports whether there is
+// one.
+func (it *Iterator[T]) Last() bool {
+	it.n = extreme(it.tree.root, rightDir)
+	return it.Valid()
+}
+
+// Seek moves the iterator to the first item greater than or equal to the
+// given one with a fresh descent from the root, and reports whether there is
+// one. Next and Prev then move on from there.
+func (it *Iterator[T]) Seek(item T) bool {
+	it.n = nil
+	for p := it.tree.root; p != nil; {
+		it.n = p
+		cmp := it.tree.compare(item, p.data)
+		if cmp == 0 {
+			return true
+		}
+		dir := leftDir
+		if cmp > 0 {
+			dir = rightDir
+		}
+		p = p.get(dir)
+	}
+	if it.n == nil {
+		return false
+	}
+	// the search ended at either neighbor of item, step past the smaller one.
+	if it.tree.compare(it.Item(), item) < 0 {
+		return it.Next()
+	}
+	return true
+}
+
+// Next moves the iterator to the following item and reports whether the
+// iterator is still valid.
+func (it *Iterator[T]) Next() bool {
+	return it.step(rightDir)
+}
+
+// Prev moves the iterator to the preceding item and reports whether the
+// iterator is still valid.
+func (it *Iterator[T]) Prev() bool {
+	return it.step(leftDir)
+}
+
+// extreme returns the last node of the subtree rooted at n toward dir, nil if
+// n is nil.
+func extreme[T any](n *node[T], dir direction) *node[T] {
+	if n == nil {
+		return nil
+	}
+	for n.get(dir) != nil {
+		n = n.get(dir)
+	}
+	return n
+}
+
+// step moves to the in-order neighbor toward dir: the extreme node of the
+// subtree on that side if any, otherwise the nearest ancestor reached from
+// its other side.
+func (it *Iterator[T]) step(dir direction) bool {
+	if it.n == nil {
+		return false
+	}
+	if child := it.n.get(dir); child != nil {
+		it.n = extreme(child, dir.opposite())
+		return true
+	}
+	child, p := it.n, it.n.parent
+	for p != nil && p.get(dir) == child {
+		child, p = p, p.parent
+	}
+	it.n = p
+	return it.Valid()
+}
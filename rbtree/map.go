@@ -0,0 +1,50 @@
+package rbtree
+
+// entry is a key-value pair stored by the nodes of an RBMap.
+type entry[K, V any] struct {
+	key   K
+	value V
+}
+
+// RBMap is an ordered map backed by a red-black tree whose nodes carry
+// key-value entries ordered by key.
+type RBMap[K, V any] struct {
+	tree *RBTree[entry[K, V]]
+}
+
+func NewMap[K, V any](compare CompareFunc[K]) *RBMap[K, V] {
+	return &RBMap[K, V]{
+		tree: New[entry[K, V]](func(a, b entry[K, V]) int {
+			return compare(a.key, b.key)
+		}),
+	}
+}
+
+// Insert stores the value under the given key, it returns true if the key
+// existed already and its value got replaced.
+func (m *RBMap[K, V]) Insert(key K, value V) bool {
+	_, replaced := m.tree.InsertOrReplace(entry[K, V]{key: key, value: value})
+	return replaced
+}
+
+// Get returns the value stored under the given key, and whether it exists.
+func (m *RBMap[K, V]) Get(key K) (_ V, _ bool) {
+	if n := m.tree.find(entry[K, V]{key: key}); n != nil {
+		return n.data.value, true
+	}
+	return
+}
+
+// Remove removes the given key, it returns the value that was stored under
+// it and whether it existed. The value is taken from the removed entry,
+// which the tree relinks rather than copies when the node has two children,
+// so it is the one inserted under the key.
+func (m *RBMap[K, V]) Remove(key K) (_ V, _ bool) {
+	e, ok := m.tree.Remove(entry[K, V]{key: key})
+	return e.value, ok
+}
+
+// Len returns the number of entries in the map.
+func (m *RBMap[K, V]) Len() int {
+	return m.tree.Len()
+}
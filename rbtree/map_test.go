@@ -0,0 +1,81 @@
+package rbtree
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap[int, string](intCompare)
+	ref := map[int]string{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(200)
+		switch r.Intn(3) {
+		case 0, 1:
+			value := string(rune('a' + i%26))
+			_, exist := ref[key]
+			if replaced := m.Insert(key, value); replaced != exist {
+				t.Fatalf("step %d: insert %d returned %v", i, key, replaced)
+			}
+			ref[key] = value
+		case 2:
+			value, ok := m.Remove(key)
+			expect, exist := ref[key]
+			if ok != exist || value != expect {
+				t.Fatalf("step %d: remove %d expect %q-%v, got %q-%v", i, key, expect, exist, value, ok)
+			}
+			delete(ref, key)
+		}
+	}
+	if m.Len() != len(ref) {
+		t.Fatalf("expect len %d, got %d", len(ref), m.Len())
+	}
+	for key := 0; key < 200; key++ {
+		value, ok := m.Get(key)
+		expect, exist := ref[key]
+		if ok != exist || value != expect {
+			t.Fatalf("get %d expect %q-%v, got %q-%v", key, expect, exist, value, ok)
+		}
+	}
+	if err := m.tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapRemoveTwoChildren(t *testing.T) {
+	type payload struct {
+		name string
+		tags []string
+	}
+	m := NewMap[int, payload](intCompare)
+	for key := 0; key < 64; key++ {
+		m.Insert(key, payload{name: fmt.Sprint("item-", key), tags: []string{fmt.Sprint(key * 7)}})
+	}
+	removed := 0
+	for key := 0; key < 64; key++ {
+		n := m.tree.find(entry[int, payload]{key: key})
+		if n.left() == nil || n.right() == nil {
+			continue
+		}
+		value, ok := m.Remove(key)
+		expect := payload{name: fmt.Sprint("item-", key), tags: []string{fmt.Sprint(key * 7)}}
+		if !ok || !reflect.DeepEqual(value, expect) {
+			t.Fatalf("remove %d: expect %v, got %v-%v", key, expect, value, ok)
+		}
+		if err := m.tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		removed++
+	}
+	if removed == 0 {
+		t.Fatal("expect some nodes with two children")
+	}
+	for key := 0; key < 64; key++ {
+		if value, ok := m.Get(key); ok && value.name != fmt.Sprint("item-", key) {
+			t.Fatalf("key %d: expect its own payload, got %v", key, value)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/bits"
 )
 
 type direction int
@@ -15,6 +16,10 @@ const (
 	rightDir
 )
 
+func (d direction) opposite() direction {
+	return 1 - d
+}
+
 type color int
 
 func (c color) String() string {
@@ -33,14 +38,19 @@ type node[T any] struct {
 	data     T
 	color    color
 	children *children[T]
+	parent   *node[T] // nil for the root
 }
 
 func (n *node[T]) get(dir direction) *node[T] {
 	return n.children.get(dir)
 }
 
+// set links node as the child of n toward dir, and n as the parent of node.
 func (n *node[T]) set(dir direction, node *node[T]) {
 	n.children.set(dir, node)
+	if node != nil {
+		node.parent = n
+	}
 }
 
 func (n *node[T]) left() *node[T] {
@@ -48,7 +58,7 @@ func (n *node[T]) left() *node[T] {
 }
 
 func (n *node[T]) setLeft(node *node[T]) {
-	n.children.set(leftDir, node)
+	n.set(leftDir, node)
 }
 
 func (n *node[T]) right() *node[T] {
@@ -56,7 +66,7 @@ func (n *node[T]) right() *node[T] {
 }
 
 func (n *node[T]) setRight(node *node[T]) {
-	n.children.set(rightDir, node)
+	n.set(rightDir, node)
 }
 
 func (n *node[T]) print(w io.Writer) error {
@@ -141,16 +151,74 @@ func (c *children[T]) right() *node[T] {
 //       'a' > 'b' -> return 1
 type CompareFunc[T any] func(a, b T) int
 
+// RBMetrics counts the rebalancing work done by the tree.
+type RBMetrics struct {
+	// Rotations is the number of single rotations, a double rotation
+	// counts as two.
+	Rotations int
+	// Recolors is the number of node color changes made by the fixups.
+	Recolors int
+}
+
 type RBTree[T any] struct {
 	root *node[T]
+	size int
 
 	compare CompareFunc[T]
+	metrics RBMetrics
 }
 
 func New[T any](compare CompareFunc[T]) *RBTree[T] {
 	return &RBTree[T]{compare: compare}
 }
 
+// NewReverse returns a tree ordered by the reverse of compare, so that Min
+// returns the largest item according to compare and InOrder descends.
+func NewReverse[T any](compare CompareFunc[T]) *RBTree[T] {
+	return New[T](func(a, b T) int {
+		return compare(b, a)
+	})
+}
+
+// BuildFromSorted returns a tree holding the given items, which must be
+// sorted in strictly ascending order according to compare, it panics
+// otherwise. The tree is built in O(n) without rotations: the items are laid
+// out as a balanced tree whose levels are all full except maybe the bottom
+// one, every node is black except those of the bottom level which are red,
+// so all paths cross the same number of black nodes.
+func BuildFromSorted[T any](compare CompareFunc[T], sorted []T) *RBTree[T] {
+	for i := 1; i < len(sorted); i++ {
+		if compare(sorted[i-1], sorted[i]) >= 0 {
+			panic(fmt.Sprintf("build input is not strictly sorted at index %d", i))
+		}
+	}
+	levels := bits.Len(uint(len(sorted))) // levels of the balanced tree
+	t := New[T](compare)
+	t.root = buildBalanced(sorted, 0, levels-1)
+	t.size = len(sorted)
+	return t
+}
+
+// buildBalanced builds the subtree of the sorted items rooted at the given
+// depth, coloring red the nodes at the bottom depth.
+func buildBalanced[T any](sorted []T, depth, bottom int) *node[T] {
+	if len(sorted) == 0 {
+		return nil
+	}
+	mid := len(sorted) / 2
+	n := &node[T]{
+		color:    black,
+		data:     sorted[mid],
+		children: newChildren[T](),
+	}
+	if depth == bottom && depth > 0 {
+		n.color = red
+	}
+	n.setLeft(buildBalanced(sorted[:mid], depth+1, bottom))
+	n.setRight(buildBalanced(sorted[mid+1:], depth+1, bottom))
+	return n
+}
+
 func (t *RBTree[T]) Insert(item T) bool {
 	pa := make([]*node[T], maxHeight)  // Nodes on stack.
 	da := make([]direction, maxHeight) // Directions moved from stack nodes.
@@ -182,6 +250,7 @@ func (t *RBTree[T]) Insert(item T) bool {
 		children: newChildren[T](),
 	}
 
+	t.size++
 	if t.root == nil {
 		t.root = n
 		t.root.color = black
@@ -198,6 +267,7 @@ func (t *RBTree[T]) Insert(item T) bool {
 				y.color = black
 				pa[k-1].color = black
 				pa[k-2].color = red
+				t.metrics.Recolors += 3
 				k -= 2
 			} else {
 				var x *node[T]
@@ -210,6 +280,7 @@ func (t *RBTree[T]) Insert(item T) bool {
 					// case I5: P is red, U, G is black and G-P-N forms a triangle
 					// x === P, y === N
 					// left rotation on x
+					t.metrics.Rotations++
 					x.set(rightDir, y.get(leftDir))
 					y.set(leftDir, x)
 					pa[k-2].set(leftDir, y)
@@ -219,16 +290,18 @@ func (t *RBTree[T]) Insert(item T) bool {
 				// case I6, P is red, U, G is black and G-P-N forms a outer line
 				// x === G, y === P
 				// right rotation on x
+				t.metrics.Rotations++
 				x.set(leftDir, y.get(rightDir))
 				y.set(rightDir, x)
 				if k-3 == 0 {
-					t.root = y
+					t.setRoot(y)
 				} else {
 					pa[k-3].set(da[k-3], y)
 				}
 
 				x.color = red
 				y.color = black
+				t.metrics.Recolors += 2
 				break
 			}
 		} else {
@@ -238,6 +311,7 @@ func (t *RBTree[T]) Insert(item T) bool {
 				y.color = black
 				pa[k-1].color = black
 				pa[k-2].color = red
+				t.metrics.Recolors += 3
 				k -= 2
 			} else {
 				var x *node[T]
@@ -250,6 +324,7 @@ func (t *RBTree[T]) Insert(item T) bool {
 					// case I5: P is red, U, G is black and G-P-N forms a triangle
 					// x === P, y === N
 					// left rotation on x
+					t.metrics.Rotations++
 					x.set(leftDir, y.get(rightDir))
 					y.set(rightDir, x)
 					pa[k-2].set(rightDir, y)
@@ -258,16 +333,18 @@ func (t *RBTree[T]) Insert(item T) bool {
 				// case I6, P is red, U, G is black and G-P-N forms a outer line
 				// x === G, y === P
 				// right rotation on x
+				t.metrics.Rotations++
 				x.set(rightDir, y.get(leftDir))
 				y.set(leftDir, x)
 				if k-3 == 0 {
-					t.root = y
+					t.setRoot(y)
 				} else {
 					pa[k-3].set(da[k-3], y)
 				}
 
 				x.color = red
 				y.color = black
+				t.metrics.Recolors += 2
 				break
 			}
 		}
@@ -278,6 +355,19 @@ func (t *RBTree[T]) Insert(item T) bool {
 	return true
 }
 
+// InsertOrReplace inserts the item, or overwrites the stored item equal to
+// it, which is useful when the comparator looks at a key only and the item
+// carries a payload. It returns the overwritten item and true, or false if
+// the item was newly inserted.
+func (t *RBTree[T]) InsertOrReplace(item T) (old T, replaced bool) {
+	if p := t.find(item); p != nil {
+		old, p.data = p.data, item
+		return old, true
+	}
+	t.Insert(item)
+	return
+}
+
 func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 	if t.root == nil {
 		return
@@ -363,9 +453,7 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 			x := pa[k-1].get(da[k-1])
 			if x != nil && x.color == red {
 				x.color = black
-				break
-			}
-			if k < 2 {
+				t.metrics.Recolors++
 				break
 			}
 			if da[k-1] == leftDir {
@@ -373,13 +461,15 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 				if w.color == red {
 					// case D3: w === S, pa[k-1] === P
 					// left rotation at P
+					t.metrics.Rotations++
 					pa[k-1].set(rightDir, w.get(leftDir))
 					w.set(leftDir, pa[k-1])
-					pa[k-2].set(da[k-2], w)
+					t.setLinkForPred(pa, da, k-2, w)
 
 					// recolor
 					w.color = black
 					pa[k-1].color = red
+					t.metrics.Recolors += 2
 
 					pa[k] = pa[k-1]
 					da[k] = leftDir
@@ -393,12 +483,14 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 					// case D1 or D4: w === S, pa[k-1] === P
 					// recolor S to red
 					w.color = red
+					t.metrics.Recolors++
 				} else {
 					if w.right() == nil || w.right().color == black {
 						y := w.get(leftDir)
 
 						// case D5: w === S, y ==== C
 						// right rotation at S
+						t.metrics.Rotations++
 						w.set(leftDir, y.right())
 						y.set(rightDir, w)
 						pa[k-1].set(rightDir, y)
@@ -406,19 +498,22 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 						// recolor
 						y.color = black
 						w.color = red
+						t.metrics.Recolors += 2
 
 						w = pa[k-1].right()
 					}
 					// case D6: w === S, pa[k-1] === P
 					// left rotation at P
+					t.metrics.Rotations++
 					pa[k-1].set(rightDir, w.left())
 					w.set(leftDir, pa[k-1])
-					pa[k-2].set(da[k-2], w)
+					t.setLinkForPred(pa, da, k-2, w)
 
 					// recolor
 					w.color = pa[k-1].color
 					pa[k-1].color = black
 					w.right().color = black
+					t.metrics.Recolors += 3
 
 					break
 				}
@@ -427,13 +522,15 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 				if w.color == red {
 					// case D3: w === S, pa[k-1] === P
 					// right rotation at P
+					t.metrics.Rotations++
 					pa[k-1].set(leftDir, w.get(rightDir))
 					w.set(rightDir, pa[k-1])
-					pa[k-2].set(da[k-2], w)
+					t.setLinkForPred(pa, da, k-2, w)
 
 					// recolor
 					w.color = black
 					pa[k-1].color = red
+					t.metrics.Recolors += 2
 
 					pa[k] = pa[k-1]
 					da[k] = rightDir
@@ -447,12 +544,14 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 					// case D1 or D4: w === S, pa[k-1] === P
 					// recolor S to red
 					w.color = red
+					t.metrics.Recolors++
 				} else {
-					if w.right() == nil || w.right().color == black {
+					if w.left() == nil || w.left().color == black {
 						y := w.get(rightDir)
 
 						// case D5: w === S, y ==== C
 						// left rotation at S
+						t.metrics.Rotations++
 						w.set(rightDir, y.left())
 						y.set(leftDir, w)
 						pa[k-1].set(leftDir, y)
@@ -460,19 +559,22 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 						// recolor
 						y.color = black
 						w.color = red
+						t.metrics.Recolors += 2
 
 						w = pa[k-1].left()
 					}
 					// case D6: w === S, pa[k-1] === P
 					// left rotation at P
+					t.metrics.Rotations++
 					pa[k-1].set(leftDir, w.right())
 					w.set(rightDir, pa[k-1])
-					pa[k-2].set(da[k-2], w)
+					t.setLinkForPred(pa, da, k-2, w)
 
 					// recolor
 					w.color = pa[k-1].color
 					pa[k-1].color = black
 					w.left().color = black
+					t.metrics.Recolors += 3
 
 					break
 				}
@@ -481,17 +583,252 @@ func (t *RBTree[T]) Remove(item T) (_ T, _ bool) {
 		}
 	}
 	p = nil
+	t.size--
 	return item, true
 }
 
 func (t *RBTree[T]) setLinkForPred(pa []*node[T], da []direction, i int, n *node[T]) {
 	if i < 0 {
-		t.root = n
+		t.setRoot(n)
 		return
 	}
 	pa[i].set(da[i], n)
 }
 
+// setRoot makes n the root of the tree, unlinking it from its former parent.
+func (t *RBTree[T]) setRoot(n *node[T]) {
+	t.root = n
+	if n != nil {
+		n.parent = nil
+	}
+}
+
+// Metrics returns the rebalancing work done since the tree was created or
+// since the last ResetMetrics.
+func (t *RBTree[T]) Metrics() RBMetrics {
+	return t.metrics
+}
+
+// ResetMetrics sets all counters of the metrics back to zero.
+func (t *RBTree[T]) ResetMetrics() {
+	t.metrics = RBMetrics{}
+}
+
+// Clear removes all items, the tree keeps its comparator and its metrics and
+// can be refilled.
+func (t *RBTree[T]) Clear() {
+	t.root, t.size = nil, 0
+}
+
+// Contains reports whether an item equal to the given one is in the tree.
+func (t *RBTree[T]) Contains(item T) bool {
+	return t.find(item) != nil
+}
+
+// find returns the node holding an item equal to the given one, or nil.
+func (t *RBTree[T]) find(item T) *node[T] {
+	for p := t.root; p != nil; {
+		cmp := t.compare(item, p.data)
+		if cmp == 0 {
+			return p
+		}
+		dir := leftDir
+		if cmp > 0 {
+			dir = rightDir
+		}
+		p = p.get(dir)
+	}
+	return nil
+}
+
+// Len returns the number of items in the tree.
+func (t *RBTree[T]) Len() int {
+	return t.size
+}
+
+// Min returns the smallest item, or false if the tree is empty.
+func (t *RBTree[T]) Min() (_ T, _ bool) {
+	return t.extreme(leftDir)
+}
+
+// Max returns the largest item, or false if the tree is empty.
+func (t *RBTree[T]) Max() (_ T, _ bool) {
+	return t.extreme(rightDir)
+}
+
+// PopMin removes and returns the smallest item, or false if the tree is
+// empty. The returned item is the one stored in the tree, not a value
+// merely comparing equal to it.
+func (t *RBTree[T]) PopMin() (_ T, _ bool) {
+	return t.pop(leftDir)
+}
+
+// PopMax removes and returns the largest item, or false if the tree is
+// empty, see PopMin.
+func (t *RBTree[T]) PopMax() (_ T, _ bool) {
+	return t.pop(rightDir)
+}
+
+func (t *RBTree[T]) pop(dir direction) (_ T, _ bool) {
+	item, ok := t.extreme(dir)
+	if !ok {
+		return
+	}
+	return t.Remove(item)
+}
+
+func (t *RBTree[T]) extreme(dir direction) (_ T, _ bool) {
+	if t.root == nil {
+		return
+	}
+	p := t.root
+	for p.get(dir) != nil {
+		p = p.get(dir)
+	}
+	return p.data, true
+}
+
+// InOrder calls visit for every item in ascending order until visit
+// returns false.
+func (t *RBTree[T]) InOrder(visit func(T) bool) {
+	t.walk(leftDir, visit)
+}
+
+// ToSlice returns all the items in ascending order.
+func (t *RBTree[T]) ToSlice() []T {
+	return t.collect(leftDir)
+}
+
+// ToSliceDesc returns all the items in descending order, it walks the tree
+// in reverse rather than reversing the ascending slice.
+func (t *RBTree[T]) ToSliceDesc() []T {
+	return t.collect(rightDir)
+}
+
+func (t *RBTree[T]) collect(dir direction) []T {
+	out := make([]T, 0, t.size)
+	t.walk(dir, func(item T) bool {
+		out = append(out, item)
+		return true
+	})
+	return out
+}
+
+// walk calls visit for every item in order until visit returns false, the
+// order is ascending if dir is leftDir and descending if dir is rightDir.
+func (t *RBTree[T]) walk(dir direction, visit func(T) bool) {
+	pa := make([]*node[T], maxHeight) // Nodes on stack.
+	k := 0                            // Stack height
+
+	p := t.root
+	for {
+		for ; p != nil; p = p.get(dir) {
+			pa[k] = p
+			k++
+		}
+		if k == 0 {
+			return
+		}
+		k--
+		p = pa[k]
+		if !visit(p.data) {
+			return
+		}
+		p = p.get(dir.opposite())
+	}
+}
+
+// ScanFrom calls visit in ascending order for at most limit items starting
+// at the first item greater than or equal to start, until visit returns
+// false. It seeks an explicit stack to start, so it costs O(log n + limit).
+func (t *RBTree[T]) ScanFrom(start T, limit int, visit func(T) bool) {
+	pa := make([]*node[T], maxHeight) // Nodes on stack.
+	k := 0                            // Stack height
+
+	// stack the nodes not smaller than start on the path to start, they
+	// are the next ones to visit.
+	for p := t.root; p != nil; {
+		if t.compare(p.data, start) >= 0 {
+			pa[k] = p
+			k++
+			p = p.left()
+		} else {
+			p = p.right()
+		}
+	}
+	for ; limit > 0 && k > 0; limit-- {
+		k--
+		p := pa[k]
+		if !visit(p.data) {
+			return
+		}
+		for p = p.right(); p != nil; p = p.left() {
+			pa[k] = p
+			k++
+		}
+	}
+}
+
+// Height returns the number of edges on the longest path from the root to a
+// leaf, which is at most 2*log2(n+1), or 0 if the tree is empty.
+func (t *RBTree[T]) Height() int {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.height() - 1
+}
+
+// height returns the number of nodes on the longest path from this node
+// down to a leaf.
+func (n *node[T]) height() int {
+	if n == nil {
+		return 0
+	}
+	l, r := n.left().height(), n.right().height()
+	if l > r {
+		return l + 1
+	}
+	return r + 1
+}
+
+// BlackHeight returns the number of black nodes on a path from the root to a
+// leaf, the same for every path of a valid tree, or 0 if the tree is empty.
+func (t *RBTree[T]) BlackHeight() int {
+	cnt := 0
+	for p := t.root; p != nil; p = p.left() {
+		if p.color == black {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// Range calls visit in ascending order for the items in [lo, hi) until visit
+// returns false. Subtrees entirely outside the window are not visited.
+func (t *RBTree[T]) Range(lo, hi T, visit func(T) bool) {
+	t.rangeFrom(t.root, lo, hi, visit)
+}
+
+// rangeFrom visits the window in the subtree rooted at n, it returns false
+// once visit asked to stop.
+func (t *RBTree[T]) rangeFrom(n *node[T], lo, hi T, visit func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := t.compare(n.data, lo) >= 0
+	belowHi := t.compare(n.data, hi) < 0
+	if aboveLo && !t.rangeFrom(n.left(), lo, hi, visit) {
+		return false
+	}
+	if aboveLo && belowHi && !visit(n.data) {
+		return false
+	}
+	if belowHi {
+		return t.rangeFrom(n.right(), lo, hi, visit)
+	}
+	return true
+}
+
 func (t *RBTree[T]) Print(w io.Writer) error {
 	if t.root == nil {
 		return nil
@@ -0,0 +1,572 @@
+package rbtree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func intCompare(a, b int) int { return a - b }
+
+// newIntTree returns a tree holding the given items.
+func newIntTree(items ...int) *RBTree[int] {
+	tree := New[int](intCompare)
+	for _, item := range items {
+		tree.Insert(item)
+	}
+	return tree
+}
+
+// randomTree returns a tree holding n distinct random items.
+func randomTree(seed int64, n int) *RBTree[int] {
+	r := rand.New(rand.NewSource(seed))
+	return newIntTree(r.Perm(n)...)
+}
+
+// items returns the items of the tree in ascending order.
+func items(tree *RBTree[int]) []int {
+	var out []int
+	tree.InOrder(func(item int) bool {
+		out = append(out, item)
+		return true
+	})
+	return out
+}
+
+func TestMetrics(t *testing.T) {
+	tree := newIntTree(1)
+	if got := tree.Metrics(); got != (RBMetrics{}) {
+		t.Fatalf("expect no rebalancing for a single item, got %+v", got)
+	}
+	// 1-2-3 forms an outer line, fixed by one rotation and recoloring 2 nodes.
+	tree.Insert(2)
+	tree.Insert(3)
+	if got, expect := tree.Metrics(), (RBMetrics{Rotations: 1, Recolors: 2}); got != expect {
+		t.Fatalf("expect %+v, got %+v", expect, got)
+	}
+
+	tree.ResetMetrics()
+	if got := tree.Metrics(); got != (RBMetrics{}) {
+		t.Fatalf("expect zero metrics after a reset, got %+v", got)
+	}
+
+	tree = randomTree(1, 1000)
+	inserted := tree.Metrics()
+	if inserted.Rotations == 0 || inserted.Recolors == 0 {
+		t.Fatalf("expect rebalancing work for 1000 inserts, got %+v", inserted)
+	}
+	for item := 0; item < 1000; item += 2 {
+		tree.Remove(item)
+	}
+	removed := tree.Metrics()
+	if removed.Rotations <= inserted.Rotations || removed.Recolors <= inserted.Recolors {
+		t.Fatalf("expect removals to add to the counters, got %+v then %+v", inserted, removed)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildFromSorted(t *testing.T) {
+	for n := 0; n <= 300; n++ {
+		sorted := make([]int, n)
+		for i := range sorted {
+			sorted[i] = i * 2
+		}
+		tree := BuildFromSorted(intCompare, sorted)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%d items: %v", n, err)
+		}
+		if got := items(tree); !reflect.DeepEqual(got, sorted) && n > 0 {
+			t.Fatalf("%d items: unexpected items %v", n, got)
+		}
+		if m := tree.Metrics(); m != (RBMetrics{}) {
+			t.Fatalf("%d items: expect no rebalancing, got %+v", n, m)
+		}
+		// the tree keeps working after the build
+		tree.Insert(-1)
+		tree.Insert(n)
+		tree.Remove(n / 2 * 2)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%d items: %v after mutation", n, err)
+		}
+	}
+}
+
+func TestBuildFromSortedUnsorted(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect a panic on unsorted input")
+		}
+	}()
+	BuildFromSorted(intCompare, []int{1, 3, 2})
+}
+
+func TestPopMinMax(t *testing.T) {
+	type record struct {
+		key int
+		tag *string
+	}
+	tree := New[record](func(a, b record) int { return a.key - b.key })
+	tags := make(map[int]*string)
+	for _, key := range rand.New(rand.NewSource(5)).Perm(64) {
+		tag := fmt.Sprint("record-", key)
+		tags[key] = &tag
+		tree.Insert(record{key: key, tag: &tag})
+	}
+	for lo, hi := 0, 63; lo < hi; lo, hi = lo+1, hi-1 {
+		got, ok := tree.PopMin()
+		if !ok || got.key != lo || got.tag != tags[lo] {
+			t.Fatalf("PopMin expect stored record %d, got %v-%v", lo, got, ok)
+		}
+		got, ok = tree.PopMax()
+		if !ok || got.key != hi || got.tag != tags[hi] {
+			t.Fatalf("PopMax expect stored record %d, got %v-%v", hi, got, ok)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.Len() != 0 {
+		t.Fatalf("expect empty tree, got %d items", tree.Len())
+	}
+	if _, ok := tree.PopMin(); ok {
+		t.Fatal("PopMin on an empty tree expect false")
+	}
+	if _, ok := tree.PopMax(); ok {
+		t.Fatal("PopMax on an empty tree expect false")
+	}
+}
+
+func TestPanickingInOrder(t *testing.T) {
+	tree := randomTree(13, 100)
+	expect := items(tree)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expect the panic to propagate")
+			}
+		}()
+		tree.InOrder(func(item int) bool {
+			if item == 42 {
+				panic("boom")
+			}
+			return true
+		})
+	}()
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("tree corrupted after a panic: %v", err)
+	}
+	if !reflect.DeepEqual(items(tree), expect) {
+		t.Fatalf("tree changed after a panic")
+	}
+}
+
+func TestInsertOrReplace(t *testing.T) {
+	type record struct {
+		key     int
+		payload string
+	}
+	tree := New[record](func(a, b record) int { return a.key - b.key })
+	for _, key := range rand.New(rand.NewSource(6)).Perm(32) {
+		if _, replaced := tree.InsertOrReplace(record{key: key, payload: "v1"}); replaced {
+			t.Fatalf("key %d: expect a new insert", key)
+		}
+	}
+	for key := 0; key < 32; key += 3 {
+		old, replaced := tree.InsertOrReplace(record{key: key, payload: "v2"})
+		if !replaced || old != (record{key: key, payload: "v1"}) {
+			t.Fatalf("key %d: expect to replace v1, got %v-%v", key, old, replaced)
+		}
+	}
+	if tree.Len() != 32 {
+		t.Fatalf("expect 32 items, got %d", tree.Len())
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	key := 0
+	tree.InOrder(func(item record) bool {
+		expect := "v1"
+		if key%3 == 0 {
+			expect = "v2"
+		}
+		if item.key != key || item.payload != expect {
+			t.Fatalf("key %d: expect payload %s, got %v", key, expect, item)
+		}
+		key++
+		return true
+	})
+}
+
+func TestRange(t *testing.T) {
+	tree := randomTree(15, 1000)
+	var got []int
+	tree.Range(100, 120, func(item int) bool {
+		got = append(got, item)
+		return true
+	})
+	expect := make([]int, 0, 20)
+	for i := 100; i < 120; i++ {
+		expect = append(expect, i)
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+
+	got = got[:0]
+	tree.Range(0, 1000, func(item int) bool {
+		got = append(got, item)
+		return len(got) < 3
+	})
+	if !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("expect an early stop after 3 items, got %v", got)
+	}
+	tree.Range(500, 500, func(int) bool {
+		t.Fatalf("expect nothing in an empty window")
+		return false
+	})
+
+	// count the nodes compared against the bounds of a skewed window.
+	compares := 0
+	counted := New[int](func(a, b int) int {
+		compares++
+		return a - b
+	})
+	for _, item := range items(tree) {
+		counted.Insert(item)
+	}
+	compares = 0
+	cnt := 0
+	counted.Range(990, 995, func(int) bool {
+		cnt++
+		return true
+	})
+	// each visited node costs two compares, a full walk would visit 1000.
+	if visited := compares / 2; cnt != 5 || visited > cnt+4*11 {
+		t.Fatalf("expect %d items visiting O(log n) nodes, visited %d", cnt, visited)
+	}
+}
+
+func TestHeight(t *testing.T) {
+	tree := New[int](intCompare)
+	if tree.Height() != 0 || tree.BlackHeight() != 0 {
+		t.Fatalf("expect 0 heights for an empty tree, got %d %d", tree.Height(), tree.BlackHeight())
+	}
+	tree.Insert(1)
+	if tree.Height() != 0 || tree.BlackHeight() != 1 {
+		t.Fatalf("expect heights 0 and 1 for a single item, got %d %d", tree.Height(), tree.BlackHeight())
+	}
+	tree = BuildFromSorted(intCompare, []int{1, 2, 3, 4, 5, 6, 7})
+	if tree.Height() != 2 || tree.BlackHeight() != 2 {
+		t.Fatalf("expect heights 2 and 2 for 7 built items, got %d %d", tree.Height(), tree.BlackHeight())
+	}
+
+	for _, n := range []int{10, 100, 1000, 10000} {
+		tree := randomTree(int64(n), n)
+		// sequential inserts are the worst case for the red-black balance.
+		seq := New[int](intCompare)
+		for i := 0; i < n; i++ {
+			seq.Insert(i)
+		}
+		for _, tree := range []*RBTree[int]{tree, seq} {
+			if bound := 2 * math.Log2(float64(n+1)); float64(tree.Height()) > bound {
+				t.Fatalf("%d items: height %d exceeds %.2f", n, tree.Height(), bound)
+			}
+			// every path crosses the black nodes, so it's at least that long.
+			if bh := tree.BlackHeight(); bh < 1 || bh-1 > tree.Height() || 2*bh < tree.Height() {
+				t.Fatalf("%d items: black height %d inconsistent with height %d", n, bh, tree.Height())
+			}
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	tree := randomTree(18, 100)
+	tree.Clear()
+	if tree.Len() != 0 || tree.root != nil || tree.Contains(5) {
+		t.Fatalf("expect an empty tree, got %d items", tree.Len())
+	}
+	for _, item := range []int{3, 1, 2} {
+		if !tree.Insert(item) {
+			t.Fatalf("expect %d inserted after a clear", item)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items(tree), []int{1, 2, 3}) {
+		t.Fatalf("expect the comparator kept, got %v", items(tree))
+	}
+}
+
+func TestNewReverse(t *testing.T) {
+	tree := NewReverse[int](intCompare)
+	for _, item := range rand.New(rand.NewSource(19)).Perm(50) {
+		tree.Insert(item)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got := items(tree)
+	for i, item := range got {
+		if item != 49-i {
+			t.Fatalf("expect a descending traversal, got %v", got)
+		}
+	}
+	if min, _ := tree.Min(); min != 49 {
+		t.Fatalf("expect Min to return the largest item, got %d", min)
+	}
+	if max, _ := tree.Max(); max != 0 {
+		t.Fatalf("expect Max to return the smallest item, got %d", max)
+	}
+	if !tree.Contains(10) || tree.Contains(50) {
+		t.Fatal("expect lookups to follow the reversed ordering")
+	}
+}
+
+func TestToSliceDesc(t *testing.T) {
+	if got := New[int](intCompare).ToSliceDesc(); len(got) != 0 {
+		t.Fatalf("expect an empty slice, got %v", got)
+	}
+	tree := randomTree(23, 300)
+	asc, desc := tree.ToSlice(), tree.ToSliceDesc()
+	if !reflect.DeepEqual(asc, items(tree)) {
+		t.Fatalf("expect ToSlice to match InOrder, got %v", asc)
+	}
+	if len(desc) != len(asc) || cap(desc) != tree.Len() {
+		t.Fatalf("expect %d items preallocated, got len %d cap %d", tree.Len(), len(desc), cap(desc))
+	}
+	for i := range asc {
+		if desc[i] != asc[len(asc)-1-i] {
+			t.Fatalf("expect the reverse of %v, got %v", asc, desc)
+		}
+	}
+}
+
+func TestScanFrom(t *testing.T) {
+	tree := New[int](intCompare)
+	for _, item := range rand.New(rand.NewSource(21)).Perm(100) {
+		tree.Insert(item * 2)
+	}
+	scan := func(start, limit int) []int {
+		var out []int
+		tree.ScanFrom(start, limit, func(item int) bool {
+			out = append(out, item)
+			return true
+		})
+		return out
+	}
+	cases := []struct {
+		name         string
+		start, limit int
+		expect       []int
+	}{
+		{name: "limit 0", start: 10, limit: 0, expect: nil},
+		{name: "start on an item", start: 10, limit: 3, expect: []int{10, 12, 14}},
+		{name: "start between items", start: 11, limit: 3, expect: []int{12, 14, 16}},
+		{name: "before the first item", start: -5, limit: 2, expect: []int{0, 2}},
+		{name: "limit hitting the last item", start: 190, limit: 5, expect: []int{190, 192, 194, 196, 198}},
+		{name: "limit exceeding the set", start: 194, limit: 10, expect: []int{194, 196, 198}},
+		{name: "past the last item", start: 199, limit: 10, expect: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := scan(c.start, c.limit); !reflect.DeepEqual(got, c.expect) {
+				t.Fatalf("expect %v, got %v", c.expect, got)
+			}
+		})
+	}
+	if got := scan(0, 1000); len(got) != 100 {
+		t.Fatalf("expect the whole set, got %d items", len(got))
+	}
+
+	var got []int
+	tree.ScanFrom(0, 10, func(item int) bool {
+		got = append(got, item)
+		return len(got) < 2
+	})
+	if !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Fatalf("expect an early stop after 2 items, got %v", got)
+	}
+}
+
+func TestIteratorSeek(t *testing.T) {
+	it := New[int](intCompare).Iterator()
+	if it.First() || it.Last() || it.Seek(1) || it.Next() || it.Prev() {
+		t.Fatal("expect an iterator of an empty tree to be invalid")
+	}
+
+	tree := New[int](intCompare)
+	for _, item := range rand.New(rand.NewSource(43)).Perm(200) {
+		tree.Insert(item * 2) // even items 0 to 398
+	}
+	it = tree.Iterator()
+	// seek forward then backward, to items present and in between
+	for _, target := range []int{-5, 0, 7, 100, 133, 398, 250, 31, 1, 0} {
+		if !it.Seek(target) {
+			t.Fatalf("seek %d: expect a valid iterator", target)
+		}
+		expect := target + target%2
+		if expect < 0 {
+			expect = 0
+		}
+		if it.Item() != expect {
+			t.Fatalf("seek %d: expect %d, got %d", target, expect, it.Item())
+		}
+		for i := expect + 2; i <= expect+20 && i <= 398; i += 2 {
+			if !it.Next() || it.Item() != i {
+				t.Fatalf("seek %d: expect next %d", target, i)
+			}
+		}
+		it.Seek(target)
+		for i := expect - 2; i >= expect-20 && i >= 0; i -= 2 {
+			if !it.Prev() || it.Item() != i {
+				t.Fatalf("seek %d: expect prev %d", target, i)
+			}
+		}
+	}
+	if it.Seek(399) {
+		t.Fatalf("expect no item after the largest, got %d", it.Item())
+	}
+
+	var got []int
+	for ok := it.First(); ok; ok = it.Next() {
+		got = append(got, it.Item())
+	}
+	if !reflect.DeepEqual(got, items(tree)) {
+		t.Fatalf("expect a full ascending walk, got %v", got)
+	}
+	got = got[:0]
+	for ok := it.Last(); ok; ok = it.Prev() {
+		got = append(got, it.Item())
+	}
+	if !reflect.DeepEqual(got, tree.ToSliceDesc()) {
+		t.Fatalf("expect a full descending walk, got %v", got)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { it.Seek(123) }); allocs != 0 {
+		t.Fatalf("expect Seek not to allocate, got %v allocations", allocs)
+	}
+
+	// the parent links the iterator steps along survive removals
+	for item := 0; item < 400; item += 6 {
+		tree.Remove(item)
+	}
+	got = got[:0]
+	for ok := it.First(); ok; ok = it.Next() {
+		got = append(got, it.Item())
+	}
+	if !reflect.DeepEqual(got, items(tree)) {
+		t.Fatalf("expect a full ascending walk after removals, got %v", got)
+	}
+	got = got[:0]
+	for ok := it.Last(); ok; ok = it.Prev() {
+		got = append(got, it.Item())
+	}
+	if !reflect.DeepEqual(got, tree.ToSliceDesc()) {
+		t.Fatalf("expect a full descending walk after removals, got %v", got)
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(47))
+	for round := 0; round < 50; round++ {
+		inA, inB := map[int]bool{}, map[int]bool{}
+		a, b := New[int](intCompare), New[int](intCompare)
+		for i := r.Intn(200); i > 0; i-- {
+			item := r.Intn(300)
+			a.Insert(item)
+			inA[item] = true
+		}
+		for i := r.Intn(200); i > 0; i-- {
+			item := r.Intn(300)
+			b.Insert(item)
+			inB[item] = true
+		}
+		var union, intersection, difference []int
+		for item := 0; item < 300; item++ {
+			if inA[item] || inB[item] {
+				union = append(union, item)
+			}
+			if inA[item] && inB[item] {
+				intersection = append(intersection, item)
+			}
+			if inA[item] && !inB[item] {
+				difference = append(difference, item)
+			}
+		}
+		for name, c := range map[string]struct {
+			tree   *RBTree[int]
+			expect []int
+		}{
+			"union":        {Union(a, b), union},
+			"intersection": {Intersection(a, b), intersection},
+			"difference":   {Difference(a, b), difference},
+		} {
+			if err := c.tree.Validate(); err != nil {
+				t.Fatalf("round %d %s: %v", round, name, err)
+			}
+			if got := items(c.tree); !reflect.DeepEqual(got, c.expect) {
+				t.Fatalf("round %d %s: expect %v, got %v", round, name, c.expect, got)
+			}
+		}
+	}
+}
+
+func TestParentPointers(t *testing.T) {
+	r := rand.New(rand.NewSource(53))
+	tree := New[int](intCompare)
+	for i := 0; i < 20000; i++ {
+		item := r.Intn(1000)
+		if r.Intn(3) == 0 {
+			tree.Remove(item)
+		} else {
+			tree.Insert(item)
+		}
+		if i%100 == 0 {
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("step %d: %v", i, err)
+			}
+		}
+	}
+	for tree.Len() > 0 {
+		tree.PopMin()
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	if it, ok := New[int](intCompare).Find(1); ok || it.Valid() {
+		t.Fatal("expect nothing found in an empty tree")
+	}
+	tree := newIntTree(0, 10, 20, 30, 40, 50, 60, 70, 80, 90)
+	it, ok := tree.Find(50)
+	if !ok || it.Item() != 50 {
+		t.Fatalf("expect 50 found")
+	}
+	if !it.Next() || it.Item() != 60 {
+		t.Fatalf("expect the successor 60 after a hit")
+	}
+	it, _ = tree.Find(50)
+	if !it.Prev() || it.Item() != 40 {
+		t.Fatalf("expect the predecessor 40 before a hit")
+	}
+
+	it, ok = tree.Find(55)
+	if ok || !it.Valid() || it.Item() != 60 {
+		t.Fatalf("expect a miss positioned at the ceiling 60")
+	}
+	if !it.Prev() || it.Item() != 50 {
+		t.Fatalf("expect 50 before the ceiling")
+	}
+	if it, ok = tree.Find(-5); ok || it.Item() != 0 {
+		t.Fatalf("expect a miss positioned at the smallest item")
+	}
+	if it, ok = tree.Find(95); ok || it.Valid() {
+		t.Fatalf("expect an invalid iterator past the largest item")
+	}
+}
@@ -0,0 +1,55 @@
+package rbtree
+
+// Union returns a new tree holding the items of either a or b. Where both
+// hold an item, the one of a is kept.
+func Union[T any](a, b *RBTree[T]) *RBTree[T] {
+	return merge(a, b, true, true, true)
+}
+
+// Intersection returns a new tree holding the items of a that b holds too.
+func Intersection[T any](a, b *RBTree[T]) *RBTree[T] {
+	return merge(a, b, false, true, false)
+}
+
+// Difference returns a new tree holding the items of a that b doesn't hold.
+func Difference[T any](a, b *RBTree[T]) *RBTree[T] {
+	return merge(a, b, true, false, false)
+}
+
+// merge walks a and b side by side in ascending order, collecting the items
+// found only in a, in both, or only in b as told, and builds the result from
+// the sorted items, so it costs O(m+n). Both trees must be ordered by the
+// same comparator, the result takes the one of a.
+func merge[T any](a, b *RBTree[T], onlyA, both, onlyB bool) *RBTree[T] {
+	x, y := a.ToSlice(), b.ToSlice()
+	out := make([]T, 0, len(x)+len(y))
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		cmp := a.compare(x[i], y[j])
+		switch {
+		case cmp < 0:
+			if onlyA {
+				out = append(out, x[i])
+			}
+			i++
+		case cmp > 0:
+			if onlyB {
+				out = append(out, y[j])
+			}
+			j++
+		default:
+			if both {
+				out = append(out, x[i])
+			}
+			i++
+			j++
+		}
+	}
+	if onlyA {
+		out = append(out, x[i:]...)
+	}
+	if onlyB {
+		out = append(out, y[j:]...)
+	}
+	return BuildFromSorted(a.compare, out)
+}
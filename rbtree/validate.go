@@ -0,0 +1,63 @@
+package rbtree
+
+import "fmt"
+
+// Validate checks the ordering and the red-black invariants: the root is
+// black, no red node has a red child and every path from the root to a nil
+// child crosses the same number of black nodes. It also checks the parent
+// pointers and the size. It returns an error describing the first
+// violation, or nil for a well-formed tree. It walks the whole tree, so it
+// costs O(n).
+func (t *RBTree[T]) Validate() error {
+	if t.root != nil && t.root.color != black {
+		return fmt.Errorf("red root")
+	}
+	if t.root != nil && t.root.parent != nil {
+		return fmt.Errorf("root has a parent")
+	}
+	cnt := 0
+	var walk func(n *node[T], lo, hi *T) (int, error)
+	walk = func(n *node[T], lo, hi *T) (int, error) {
+		if n == nil {
+			return 1, nil
+		}
+		cnt++
+		if (lo != nil && t.compare(*lo, n.data) >= 0) || (hi != nil && t.compare(n.data, *hi) >= 0) {
+			return 0, fmt.Errorf("item %v out of order", n.data)
+		}
+		for _, child := range []*node[T]{n.left(), n.right()} {
+			if child != nil && child.parent != n {
+				return 0, fmt.Errorf("item %v has a bad parent pointer", child.data)
+			}
+		}
+		if n.color == red {
+			for _, child := range []*node[T]{n.left(), n.right()} {
+				if child != nil && child.color == red {
+					return 0, fmt.Errorf("red item %v has a red child", n.data)
+				}
+			}
+		}
+		lb, err := walk(n.left(), lo, &n.data)
+		if err != nil {
+			return 0, err
+		}
+		rb, err := walk(n.right(), &n.data, hi)
+		if err != nil {
+			return 0, err
+		}
+		if lb != rb {
+			return 0, fmt.Errorf("item %v has black heights %d and %d", n.data, lb, rb)
+		}
+		if n.color == black {
+			lb++
+		}
+		return lb, nil
+	}
+	if _, err := walk(t.root, nil, nil); err != nil {
+		return err
+	}
+	if cnt != t.size {
+		return fmt.Errorf("tree holds %d items, size is %d", cnt, t.size)
+	}
+	return nil
+}